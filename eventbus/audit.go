@@ -0,0 +1,28 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+)
+
+// AuditSink records published events, for example to persist an ordered event
+// log for audit and replay.
+type AuditSink interface {
+	Record(Event) error
+}
+
+// audit records the event to the audit sink, if one is configured. Records are
+// serialized so the sink sees events in publish order. Errors are reported to
+// the error handler.
+func (b *bus) audit(ctx context.Context, e Event) {
+	if b.auditSink == nil {
+		return
+	}
+
+	b.auditMu.Lock()
+	defer b.auditMu.Unlock()
+
+	if err := b.auditSink.Record(e); err != nil {
+		b.handleError(ctx, fmt.Errorf("audit error; event: %v: %w", e, err))
+	}
+}
@@ -0,0 +1,142 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+)
+
+type memoryAuditSink struct {
+	events []eventbus.Event
+	err    error
+}
+
+func (s *memoryAuditSink) Record(e eventbus.Event) error {
+	s.events = append(s.events, e)
+	return s.err
+}
+
+func TestPublish_WithAuditSink_RecordsEventsInOrder(t *testing.T) {
+	ctx := context.Background()
+	sink := &memoryAuditSink{}
+	bus := eventbus.New(eventbus.WithAuditSinkBusOpt(sink))
+
+	names := []EventName{"first", "second", "third"}
+	for i, name := range names {
+		if err := bus.Publish(ctx, name, i); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if len(sink.events) != len(names) {
+		t.Fatal("expected every event to be recorded", sink.events)
+	}
+	for i, name := range names {
+		if sink.events[i].Name != name || sink.events[i].Data != i || sink.events[i].ID == "" {
+			t.Error("expected events to be recorded in order", sink.events[i])
+		}
+	}
+}
+
+func TestPublish_WithAuditSinkBeforeDispatch_RecordsBeforeHandler(t *testing.T) {
+	ctx := context.Background()
+	sink := &memoryAuditSink{}
+	bus := eventbus.New(eventbus.WithAuditSinkBusOpt(sink))
+	recorded := 0
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		recorded = len(sink.events)
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if recorded != 1 {
+		t.Error("expected event to be recorded before dispatch")
+	}
+}
+
+func TestPublish_WithAuditSinkAfterDispatch_RecordsAfterHandler(t *testing.T) {
+	ctx := context.Background()
+	sink := &memoryAuditSink{}
+	bus := eventbus.New(eventbus.WithAuditSinkBusOpt(sink), eventbus.WithAuditAfterDispatchBusOpt())
+	recorded := 0
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		recorded = len(sink.events)
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if recorded != 0 || len(sink.events) != 1 {
+		t.Error("expected event to be recorded after dispatch")
+	}
+}
+
+func TestPublish_WithFailingAuditSink_ReportsError(t *testing.T) {
+	ctx := context.Background()
+	sinkErr := errors.New("disk full")
+	var reported error
+	bus := eventbus.New(
+		eventbus.WithAuditSinkBusOpt(&memoryAuditSink{err: sinkErr}),
+		eventbus.WithErrorHandlerBusOpt(func(_ context.Context, err error) {
+			reported = err
+		}),
+	)
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if !errors.Is(reported, sinkErr) {
+		t.Error("expected sink error to be reported", reported)
+	}
+}
+
+func TestPublish_WithTimestampOption_RecordsProvidedTimestamp(t *testing.T) {
+	ctx := context.Background()
+	sink := &memoryAuditSink{}
+	bus := eventbus.New(eventbus.WithAuditSinkBusOpt(sink))
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 0, time.FixedZone("UTC+3", 3*60*60))
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithTimestampEventOpt(ts)); err != nil {
+		t.Error("expected no error", err)
+	}
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if len(sink.events) != 2 {
+		t.Fatal("expected both events to be recorded", sink.events)
+	}
+	if got := sink.events[0].Timestamp; !got.Equal(ts) || got.Location() != time.UTC {
+		t.Error("expected the provided timestamp in UTC", got)
+	}
+	if got := sink.events[1].Timestamp; time.Since(got) > time.Minute {
+		t.Error("expected the current time without the option", got)
+	}
+}
+
+func TestPublish_WithTimestampOptionAndUnhandledEvent_HandlerSeesTimestamp(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithUnhandledEventBusOpt(EventName("unhandled")))
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var seen time.Time
+	bus.On(EventName("unhandled")).Do(func(_ context.Context, _ eventbus.Stringer, data interface{}) error {
+		seen = data.(eventbus.Event).Timestamp
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithTimestampEventOpt(ts)); err != nil {
+		t.Error("expected no error", err)
+	}
+	if !seen.Equal(ts) {
+		t.Error("expected the original event to carry the provided timestamp", seen)
+	}
+}
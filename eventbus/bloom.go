@@ -0,0 +1,75 @@
+package eventbus
+
+import (
+	"math"
+	"strconv"
+)
+
+type bloomMatcher struct {
+	bits   []uint64
+	m      uint64
+	k      uint64
+	length int
+}
+
+// BloomNameMatcher matches events whose name is one of the provided names,
+// using a bloom filter sized for the given false positive rate. Membership is
+// probabilistic: every listed name matches, but an unlisted name may also
+// match at roughly the false positive rate. This makes it suited to be a cheap
+// pre-filter for very large name sets, rather than an exact matcher.
+func BloomNameMatcher(names []string, falsePositiveRate float64) Matcher {
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+
+	n := math.Max(float64(len(names)), 1)
+	m := math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	k := math.Max(math.Round(m/n*math.Ln2), 1)
+
+	b := &bloomMatcher{
+		bits:   make([]uint64, (uint64(m)+63)/64),
+		m:      uint64(m),
+		k:      uint64(k),
+		length: len(names),
+	}
+	for _, name := range names {
+		h1, h2 := bloomHash(name)
+		for i := uint64(0); i < b.k; i++ {
+			bit := (h1 + i*h2) % b.m
+			b.bits[bit/64] |= 1 << (bit % 64)
+		}
+	}
+
+	return b
+}
+
+// bloomHash derives the two hashes used for double hashing from a single
+// 64-bit FNV-1a hash, computed inline to avoid allocating.
+func bloomHash(s string) (uint64, uint64) {
+	const (
+		offset = 14695981039346656037
+		prime  = 1099511628211
+	)
+
+	sum := uint64(offset)
+	for i := 0; i < len(s); i++ {
+		sum ^= uint64(s[i])
+		sum *= prime
+	}
+	return sum & math.MaxUint32, sum>>32 | 1
+}
+
+func (b *bloomMatcher) Match(name Stringer, data interface{}) bool {
+	h1, h2 := bloomHash(name.String())
+	for i := uint64(0); i < b.k; i++ {
+		bit := (h1 + i*h2) % b.m
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *bloomMatcher) String() string {
+	return "bloom[" + strconv.Itoa(b.length) + "]"
+}
@@ -2,59 +2,270 @@ package eventbus
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
 
 	"github.com/almahoozi/go-eventbus/pkg/id"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/exp/slog"
+	"golang.org/x/sync/errgroup"
 	"golang.org/x/sync/semaphore"
+	"golang.org/x/sync/singleflight"
+	"golang.org/x/time/rate"
 )
 
+// observerErrorsBuffer is the capacity of the ObserverErrors channel.
+const observerErrorsBuffer = 64
+
 type bus struct {
-	observers       map[string]observerWithOptions
-	subscriptions   map[Stringer][]*subscription
-	wg              sync.WaitGroup
-	close           chan struct{}
-	concurrency     int64
-	continueOnError bool
+	opts                  []busOpt
+	mu                    sync.Mutex
+	registry              atomic.Pointer[registry]
+	seq                   atomic.Uint64
+	wg                    sync.WaitGroup
+	close                 chan struct{}
+	closeOnce             sync.Once
+	concurrency           int64
+	observerBatch         int64
+	continueOnError       bool
+	publishHook           func(Event) func(error)
+	singleFlightKey       func(Event) string
+	singleFlight          singleflight.Group
+	errorHandler          func(context.Context, error)
+	captureStack          bool
+	caseInsensitive       bool
+	inFlightMu            sync.Mutex
+	inFlight              map[string]InFlightPublish
+	lastActive            time.Time
+	pending               atomic.Int64
+	auditSink             AuditSink
+	auditAfter            bool
+	auditMu               sync.Mutex
+	recorder              io.Writer
+	recorderMu            sync.Mutex
+	warnEmpty             bool
+	clock                 Clock
+	completed             atomic.Int64
+	rejectNilData         bool
+	contextKeys           []interface{}
+	observerErrors        chan error
+	captureOnError        func(Event)
+	criticalFirst         bool
+	unhandledName         Stringer
+	observerFilter        []Matcher
+	logger                *slog.Logger
+	concurrentSubscribers bool
+	deterministic         bool
+	deadLetterFn          func(context.Context, Event, error)
+	deadLetterTimeout     time.Duration
+	fair                  bool
+	fairQueue             *fairQueue
+	queueSize             int
+	queueWorkers          int
+	queue                 *publishQueue
+	closeDrainTimeout     time.Duration
+	tracer                trace.Tracer
+	metrics               Metrics
+	startup               bool
+	started               atomic.Bool
+	errorRates            *errorRates
+	defaultHandlerTimeout time.Duration
+	middlewares           []Middleware
+	dispatchFn            PublishFunc
+	panicHandler          func(recovered interface{}, stack []byte)
+	cancelOnClose         bool
+	rateLimiters          map[string]*rate.Limiter
+	rateLimitWait         bool
+	groupTurns            sync.Map
+	deduper               *deduper
+	dedupeSilent          bool
 }
 
 func New(opts ...busOpt) *bus {
 	b := &bus{
-		observers:     make(map[string]observerWithOptions),
-		subscriptions: make(map[Stringer][]*subscription),
-		close:         make(chan struct{}),
-		concurrency:   10,
+		opts:              opts,
+		close:             make(chan struct{}),
+		concurrency:       10,
+		observerBatch:     1,
+		inFlight:          make(map[string]InFlightPublish),
+		clock:             realClock{},
+		observerErrors:    make(chan error, observerErrorsBuffer),
+		deadLetterTimeout: defaultDeadLetterTimeout,
+		metrics:           noopMetrics{},
 	}
+	b.registry.Store(newRegistry())
 	for _, opt := range opts {
 		opt(b)
 	}
+	if b.deterministic {
+		// Options given after WithDeterministicBusOpt mustn't raise it.
+		b.concurrency = 1
+	}
+	// With a queue, its workers take turns between event names instead.
+	if b.queueWorkers > 0 {
+		b.queue = newPublishQueue(b.queueSize, b.queueWorkers)
+	} else if b.fair {
+		b.fairQueue = newFairQueue(int(b.concurrency))
+	}
+	b.dispatchFn = b.chain()
 	return b
 }
 
-// Subscribes to an event by name.
+// Returns a new bus with the same options, subscriptions and observers. The
+// handlers and observers are shared, but the registrations are not: the buses
+// are independent, so subscribing to, unsubscribing from or closing one
+// doesn't affect the other.
+func (b *bus) Clone() *bus {
+	c := New(b.opts...)
+	c.seq.Store(b.seq.Load())
+
+	r := b.load().clone()
+	for key, subs := range r.subscriptions {
+		cloned := make([]*subscription, len(subs))
+		for i, s := range subs {
+			cloned[i] = s.cloneTo(c)
+			if _, ok := r.scan[s.id]; ok {
+				r.scan[s.id] = cloned[i]
+			}
+		}
+		r.subscriptions[key] = cloned
+	}
+	c.registry.Store(r)
+
+	return c
+}
+
+// load returns the current registry snapshot, which must not be modified.
+func (b *bus) load() *registry {
+	return b.registry.Load()
+}
+
+// update applies fn to a copy of the current registry and swaps the copy in.
+// Writers are serialized; readers are never blocked.
+func (b *bus) update(fn func(r *registry)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	r := b.load().clone()
+	fn(r)
+	b.registry.Store(r)
+}
+
+// Subscribes to an event by name. Subscriptions matching an event run in order
+// of descending Priority, then in registration order.
 func (b *bus) On(name Stringer) *subscription {
-	s := subscription{
-		id:       id.New(),
-		matchers: []Matcher{ExactMatcher(name)},
+	return b.on(name, "")
+}
+
+// Subscribes to an event by name as a member of the group. Of the members of a
+// group that match an event, only one handles it, taking turns round-robin.
+// Subscriptions outside the group are unaffected.
+func (b *bus) OnGroup(group string, name Stringer) *subscription {
+	return b.on(name, group)
+}
+
+func (b *bus) on(name Stringer, group string) *subscription {
+	var key Stringer = name
+	matcher := ExactMatcher(name)
+	if b.caseInsensitive {
+		// All spellings of a name share the same registry key.
+		key = noMatch(foldName(name.String()))
+		matcher = exactFoldMatcher(name)
 	}
-	b.subscriptions[name] = append(b.subscriptions[name], &s)
-	return &s
+
+	s := newSubscription(b, id.New(), matcher)
+	s.group = group
+	b.update(func(r *registry) {
+		r.addSubscription(key, s)
+	})
+	return s
+}
+
+// foldName returns the registry key of a name on a case-insensitive bus. Names
+// that strings.EqualFold considers equal get the same key, which is the name
+// lower-cased for ASCII.
+func foldName(name string) string {
+	return strings.Map(func(r rune) rune {
+		// Every rune that folds to r is mapped to the same one of them.
+		min := r
+		for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+			if f < min {
+				min = f
+			}
+		}
+		return unicode.ToLower(min)
+	}, name)
 }
 
 // Subscribes to an event by arbitrary matchers.
 func (b *bus) When(matchers ...Matcher) *subscription {
-	s := subscription{
-		id:       id.New(),
-		matchers: matchers,
-	}
+	s := newSubscription(b, id.New(), matchers...)
+	b.addMatcherSubscription(s)
+	return s
+}
+
+// addMatcherSubscription registers a subscription made by matchers, which is
+// matched against every event rather than looked up by name.
+func (b *bus) addMatcherSubscription(s *subscription) {
 	// We don't want to accidentally match on the string for non-string matchers.
 	key := noMatch("id:" + s.id)
-	b.subscriptions[key] = append(b.subscriptions[key], &s)
-	return &s
+	b.update(func(r *registry) {
+		r.addSubscription(key, s)
+		r.scanSubscription(s)
+	})
+}
+
+// Subscribes a handler to every event. Unlike an observer, the handler runs
+// with the other subscriptions, so its errors are returned to the publisher and
+// it is subject to handler timeouts and continueOnError.
+func (b *bus) OnAny(fn Handler) *subscription {
+	s := b.When(AllMatcher{})
+	s.Do(fn)
+	return s
+}
+
+// Moves a subscription to the provided index among the subscriptions to the
+// same event name, changing the order in which their handlers run. Returns
+// false if the subscription doesn't exist.
+func (b *bus) MoveSubscription(id string, toIndex int) bool {
+	moved := false
+	b.update(func(r *registry) {
+		moved = r.moveSubscription(id, toIndex)
+	})
+	return moved
+}
+
+// Removes a subscription so that its handlers are no longer called. Publishes
+// already in progress may still call them. Returns false if the subscription
+// doesn't exist.
+func (b *bus) Unsubscribe(s *subscription) bool {
+	if s == nil {
+		return false
+	}
+
+	removed := false
+	b.update(func(r *registry) {
+		removed = r.removeSubscription(s.id)
+	})
+	return removed
 }
 
 // Publishes an event with the provided name and data.
 func (b *bus) Publish(ctx context.Context, name Stringer, data interface{}, opts ...eventOpt) error {
+	return b.publishEvent(ctx, name, data, nil, opts)
+}
+
+// publishEvent creates the event and publishes it, collecting its results into
+// report if it isn't nil.
+func (b *bus) publishEvent(ctx context.Context, name Stringer, data interface{}, report *publishReport, opts []eventOpt) error {
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
@@ -63,75 +274,759 @@ func (b *bus) Publish(ctx context.Context, name Stringer, data interface{}, opts
 		return ErrBusClosed
 	}
 
-	e := newEvent(name, data)
+	if b.rejectNilData && data == nil {
+		return ErrNilData
+	}
+
+	if b.startup && b.started.CompareAndSwap(false, true) {
+		// Publishes racing the first one don't wait for the startup event, so
+		// that its handlers can publish too.
+		if err := b.publish(ctx, newEvent(EventBusStarted, nil, b.clock.Now())); err != nil {
+			b.handleError(ctx, fmt.Errorf("startup publish error: %w", err))
+		}
+	}
+
+	e := newEvent(name, data, b.clock.Now())
 	for _, opt := range opts {
 		opt(&e)
 	}
+	e.report = report
+	e.handlerTimeout = shortestDuration(b.defaultHandlerTimeout, e.handlerTimeout)
+	// The key is remembered up front, so that concurrent duplicates are caught,
+	// and forgotten if the publish isn't accepted.
+	release := func() {}
+	if b.deduper != nil {
+		now := b.clock.Now()
+		if b.deduper.duplicate(e, now) {
+			if b.dedupeSilent {
+				return nil
+			}
+			return fmt.Errorf("%w: %s", ErrDuplicate, e.Name)
+		}
+		release = func() { b.deduper.release(e, now) }
+	}
+	if err := b.rateLimit(ctx, e); err != nil {
+		release()
+		return err
+	}
+	if b.queue != nil {
+		e.async = true
+	}
+	if b.deterministic {
+		e.inline, e.async = true, false
+	}
+
+	if e.async {
+		// The publish outlives the caller, so it must not be canceled with ctx,
+		// nor report to it.
+		pctx := b.detach(ctx)
+		e.report = nil
+		b.wg.Add(1)
+		b.pending.Add(1)
+		run := func() {
+			defer b.wg.Done()
+			defer b.pending.Add(-1)
+			if b.queue != nil {
+				b.metrics.SetQueueDepth(b.queue.depth())
+			}
+			if err := b.dispatchFn(pctx, e); err != nil {
+				b.handleError(pctx, fmt.Errorf("async publish error; event: %v: %w", e, err))
+			}
+		}
+		switch {
+		case b.queue != nil:
+			// Only waiting for room in the queue is canceled with ctx.
+			drop := func() {
+				defer b.wg.Done()
+				defer b.pending.Add(-1)
+				b.metrics.SetQueueDepth(b.queue.depth())
+				b.deadLetter(pctx, e, fmt.Errorf("%w: close drain timeout exceeded", ErrBusClosed))
+			}
+			key := ""
+			if b.fair {
+				key = e.Name.String()
+			}
+			if err := b.queue.push(ctx, key, run, drop); err != nil {
+				b.pending.Add(-1)
+				b.wg.Done()
+				release()
+				return err
+			}
+			b.metrics.SetQueueDepth(b.queue.depth())
+		case b.fairQueue != nil:
+			b.fairQueue.push(e.Name.String(), run)
+		default:
+			go run()
+		}
+		return nil
+	}
+
+	return b.dispatchFn(ctx, e)
+}
 
+// publish dispatches the event on the calling goroutine.
+func (b *bus) publish(ctx context.Context, e Event) (err error) {
 	b.wg.Add(1)
 	defer b.wg.Done()
+	defer b.completed.Add(1)
 
-	return doWithTimeout(ctx, e.publishTimeout, func(ctx context.Context) error {
-		if err := b.publishToObservers(ctx, e); err != nil {
-			return err
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer b.track(e, cancel)()
+	if b.cancelOnClose {
+		var stop func()
+		ctx, stop = b.cancelOnClosed(ctx)
+		defer stop()
+	}
+	ctx = context.WithValue(ctx, publishStartKey{}, b.clock.Now())
+	if len(e.Headers) > 0 {
+		ctx = context.WithValue(ctx, headersKey{}, e.Headers)
+	}
+	ctx = context.WithValue(ctx, eventKey{}, e)
+	b.log(ctx, "event published", "event", e.ID, "name", e.Name.String())
+	if b.tracer != nil {
+		var end func(error)
+		ctx, end = b.startSpan(ctx, e.Name.String(), eventIDKey.String(e.ID))
+		defer func() { end(err) }()
+	}
+	b.metrics.IncPublished(e.Name.String())
+	defer func() {
+		if errors.Is(err, ErrPublishTimeout) {
+			b.metrics.IncTimeout(e.Name.String())
 		}
+	}()
+	if b.errorRates != nil {
+		defer func() { b.errorRates.record(e.Name.String(), b.clock.Now(), err != nil) }()
+	}
 
+	if b.publishHook != nil {
+		if done := b.publishHook(e); done != nil {
+			defer func() { done(err) }()
+		}
+	}
+
+	b.record(ctx, e)
+	if b.auditAfter {
+		defer b.audit(ctx, e)
+	} else {
+		b.audit(ctx, e)
+	}
+
+	run := func(ctx context.Context) error {
+		if b.singleFlightKey != nil {
+			if key := b.singleFlightKey(e); key != "" {
+				_, err, _ := b.singleFlight.Do(key, func() (interface{}, error) {
+					return nil, b.dispatch(ctx, e)
+				})
+				return err
+			}
+		}
+
+		return b.dispatch(ctx, e)
+	}
+
+	if e.inline {
+		return run(ctx)
+	}
+	return doWithTimeout(ctx, e.publishTimeout, ErrPublishTimeout, run)
+}
+
+// Publishes an event only if at least minSubscribers subscriptions match it,
+// counting the members of a group once. Otherwise nothing is dispatched and ErrInsufficientSubscribers is returned.
+func (b *bus) PublishRequire(ctx context.Context, name Stringer, data interface{}, minSubscribers int, opts ...eventOpt) error {
+	if n := b.countMatching(ctx, name, data); n < minSubscribers {
+		return fmt.Errorf("%w: %d of %d", ErrInsufficientSubscribers, n, minSubscribers)
+	}
+
+	return b.Publish(ctx, name, data, opts...)
+}
+
+// Publishes the same data under each of the provided names, in order, as
+// separate events with their own IDs. Every name is published even if some
+// fail; their errors are joined.
+func (b *bus) PublishMulti(ctx context.Context, names []Stringer, data interface{}, opts ...eventOpt) error {
+	var errs []error
+	for _, name := range names {
+		if err := b.Publish(ctx, name, data, opts...); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return joinErrors(errs...)
+}
+
+// countMatching returns the number of subscriptions that would handle the
+// event. The members of a group take turns, so a group counts once.
+func (b *bus) countMatching(ctx context.Context, name Stringer, data interface{}) int {
+	n := 0
+	var groups map[string]bool
+	for _, subs := range b.load().subscriptions {
+		for _, s := range subs {
+			if !s.load().match(ctx, name, data) {
+				continue
+			}
+			if s.group != "" {
+				if groups[s.group] {
+					continue
+				}
+				if groups == nil {
+					groups = make(map[string]bool)
+				}
+				groups[s.group] = true
+			}
+			n++
+		}
+	}
+	return n
+}
+
+// dispatch delivers the event to observers and subscriptions. Observers are
+// scheduled on their own goroutine so that subscription handlers start without
+// waiting for observer slots to free up. With WithCriticalObserversFirstBusOpt,
+// critical observers run first, in order, and a failure aborts the publish.
+func (b *bus) dispatch(ctx context.Context, e Event) error {
+	r := b.load()
+	if b.unhandledName != nil && !e.unhandled && !b.observed(e, r) && b.countMatching(ctx, e.Name, e.Data) == 0 {
+		// Nothing would receive the event, so hand it to the unhandled event
+		// subscribers instead. Unhandled events are never redirected again.
+		if e.trace != nil || e.report != nil {
+			b.traceMatches(e, r, nil)
+		}
+		u := newEvent(b.unhandledName, e, b.clock.Now())
+		u.unhandled = true
+		return b.publish(ctx, u)
+	}
+
+	if b.criticalFirst && b.observable(e) {
+		for _, o := range r.critical {
+			if !o.match(e) {
+				continue
+			}
+			if err := b.runObserver(ctx, e, o); err != nil {
+				return fmt.Errorf("critical observer error; event: %v: %w", e, err)
+			}
+		}
+	}
+
+	if b.deterministic {
+		return b.dispatchInOrder(ctx, e, r)
+	}
+	if len(r.observers) == 0 {
+		// There is nothing to notify alongside the subscriptions.
 		return b.publishToSubscriptions(ctx, e)
-	})
+	}
+
+	observed := make(chan error, 1)
+	go func() {
+		observed <- b.publishToObservers(ctx, e, r)
+	}()
+
+	err := b.publishToSubscriptions(ctx, e)
+	if oerr := <-observed; oerr != nil && !errors.Is(err, oerr) && !errors.Is(oerr, err) {
+		return joinErrors(err, oerr)
+	}
+
+	return err
+}
+
+// dispatchInOrder notifies the observers in the order they were added, and then
+// runs the subscriptions, all on the calling goroutine. Errors are returned as
+// by the concurrent dispatch.
+func (b *bus) dispatchInOrder(ctx context.Context, e Event, r *registry) error {
+	var oerrs []error
+	if b.observable(e) {
+		observers := make([]observerWithOptions, 0, len(r.observers))
+		for _, o := range r.observers {
+			if b.criticalFirst && o.opts.critical || !o.match(e) {
+				continue
+			}
+			observers = append(observers, o)
+		}
+		sort.Slice(observers, func(i, j int) bool {
+			return observers[i].seq < observers[j].seq
+		})
+
+		for _, o := range observers {
+			if err := b.runObserver(ctx, e, o); err != nil {
+				err = fmt.Errorf("observer error; event: %v: %w", e, err)
+				b.observerError(err)
+				oerrs = append(oerrs, err)
+			}
+		}
+	}
+
+	err := b.publishToSubscriptions(ctx, e)
+	if len(oerrs) == 0 {
+		return err
+	}
+	if !b.continueOnError {
+		return joinErrors(err, oerrs[0])
+	}
+	return joinErrors(append([]error{err}, oerrs...)...)
+}
+
+// publishToObservers notifies the observers and waits for them to finish or
+// for ctx to be done. Observer errors are returned, joined if continueOnError
+// is set; they are also sent to ObserverErrors.
+func (b *bus) publishToObservers(ctx context.Context, e Event, r *registry) error {
+	var obs observation
+	if err := b.notifyObservers(ctx, e, r, &obs); err != nil {
+		return err
+	}
+	if obs.started == 0 {
+		return nil
+	}
+	if err := obs.wait(ctx); err != nil {
+		return err
+	}
+
+	if len(obs.errs) == 0 {
+		return nil
+	}
+	if !b.continueOnError {
+		return obs.errs[0]
+	}
+	return joinErrors(obs.errs...)
 }
 
-func (b *bus) publishToObservers(ctx context.Context, e Event) error {
+// notifyObservers starts notifying the observers, limited by the bus
+// concurrency, and returns once all of them have been started. If ctx is done
+// before then, including while waiting for a concurrency slot, the remaining
+// observers are not notified and the context error is returned. Observers that
+// were already started keep running, and Flush still waits for them.
+func (b *bus) notifyObservers(ctx context.Context, e Event, r *registry, obs *observation) error {
+	if !b.observable(e) {
+		return nil
+	}
+	canceled := func(err error) error {
+		return fmt.Errorf("observers not notified; event: %v, started: %d: %w", e, obs.started, err)
+	}
+
+	observers := r.observers
+	count := int64(len(observers))
+	if b.criticalFirst {
+		// Critical observers already ran during dispatch.
+		count -= int64(len(r.critical))
+	}
+	// Observers that don't want the event are skipped before a goroutine is
+	// spawned for them.
+	skip := func(o observerWithOptions) bool {
+		return b.criticalFirst && o.opts.critical || !o.match(e)
+	}
+
+	// When the limit can never be reached there is nothing to acquire, so the
+	// semaphore is skipped entirely.
+	if b.concurrency <= 0 || b.concurrency >= count {
+		for _, o := range observers {
+			if ctx.Err() != nil {
+				return canceled(ctx.Err())
+			}
+			if skip(o) {
+				continue
+			}
+
+			b.observe(ctx, e, o, obs, func() {})
+		}
+
+		return nil
+	}
+
 	s := semaphore.NewWeighted(b.concurrency)
-	for _, o := range b.observers {
+
+	// Slots are acquired in batches of up to observerBatch and released one at
+	// a time as each observer finishes.
+	batch := b.observerBatch
+	if batch > b.concurrency {
+		batch = b.concurrency
+	}
+	remaining := count
+	var acquired int64
+
+	for _, o := range observers {
 		if ctx.Err() != nil {
-			return ctx.Err()
+			return canceled(ctx.Err())
+		}
+		if skip(o) {
+			continue
+		}
+
+		if acquired == 0 {
+			n := batch
+			if n > remaining {
+				n = remaining
+			}
+			if err := s.Acquire(ctx, n); err != nil {
+				return canceled(err)
+			}
+			// Acquire may succeed even though ctx is done, such as when an
+			// observer canceled with ctx frees its slot.
+			if ctx.Err() != nil {
+				s.Release(n)
+				return canceled(ctx.Err())
+			}
+			acquired = n
+		}
+		acquired--
+		remaining--
+
+		b.observe(ctx, e, o, obs, func() { s.Release(1) })
+	}
+
+	return nil
+}
+
+// observable reports whether the event passes the bus-wide observer filter,
+// and wasn't published with WithoutObserversEventOpt.
+func (b *bus) observable(e Event) bool {
+	if e.noObservers {
+		return false
+	}
+	if len(b.observerFilter) == 0 {
+		return true
+	}
+	for _, m := range b.observerFilter {
+		if m.Match(e.Name, e.Data) {
+			return true
 		}
+	}
+	return false
+}
+
+// observed reports whether any of the observers in r is notified of the
+// event.
+func (b *bus) observed(e Event, r *registry) bool {
+	if !b.observable(e) {
+		return false
+	}
+	for _, o := range r.observers {
+		if o.match(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// observation collects the results of the observers notified by a publish.
+type observation struct {
+	started int
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	errs    []error
+}
+
+func (o *observation) done(err error) {
+	if err != nil {
+		o.mu.Lock()
+		o.errs = append(o.errs, err)
+		o.mu.Unlock()
+	}
+	o.wg.Done()
+}
+
+// wait waits for the observers to finish, or for ctx to be done.
+func (o *observation) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		o.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+		return nil
+	}
+}
 
-		err := s.Acquire(ctx, 1)
+// observe notifies the observer on a new goroutine, which is tracked by the
+// bus wait group. release is called once the observer returns. The observer
+// gets the publish context, so it is canceled along with the publish, such as
+// when it times out or is canceled through InFlight; the errors of observers
+// still running by then are only sent to ObserverErrors.
+func (b *bus) observe(ctx context.Context, e Event, o observerWithOptions, obs *observation, release func()) {
+	b.wg.Add(1)
+	obs.started++
+	obs.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		defer release()
+		err := b.runObserver(ctx, e, o)
+		if errors.Is(err, ErrHandlerTimeout) {
+			b.logErr(ctx, "observer timed out", "observer", o.id, "event", e.ID, "name", e.Name.String())
+		}
 		if err != nil {
-			return err
+			err = fmt.Errorf("observer error; event: %v: %w", e, err)
+			b.observerError(err)
 		}
+		obs.done(err)
+	}()
+}
 
-		o := o
-		go func() {
-			defer s.Release(1)
-			_ = doWithTimeout(ctx, shortestDuration(e.handlerTimeout, o.opts.timeout), func(ctx context.Context) error {
-				o.Observe(ctx, e.Name, e.Data)
-				return nil
-			})
+// runObserver notifies the observer on the calling goroutine, returning its
+// error, timeout or panic.
+func (b *bus) runObserver(ctx context.Context, e Event, o observerWithOptions) error {
+	ctx, release := b.scope(ctx)
+	defer release()
+	start := b.clock.Now()
+	observe := func(ctx context.Context) (err error) {
+		// A panicking observer is reported and stays registered; it must not
+		// take down the program.
+		defer func() {
+			if r := recover(); r != nil {
+				err = b.recovered(ctx, e, "observer panicked", r)
+			}
 		}()
+
+		return o.Observe(ctx, e.Name, e.Data)
 	}
 
-	return nil
+	var err error
+	if b.tracer != nil {
+		var end func(error)
+		ctx, end = b.startSpan(ctx, "observer", eventIDKey.String(e.ID), observerIDKey.String(o.id))
+		defer func() { end(err) }()
+	}
+	if b.deterministic {
+		err = observe(ctx)
+	} else {
+		err = doWithTimeout(ctx, shortestDuration(e.handlerTimeout, o.opts.timeout), ErrHandlerTimeout, observe)
+	}
+	b.countError(e, err)
+	if e.report != nil {
+		e.report.observer(ObserverResult{ObserverID: o.id, Err: err, Duration: b.clock.Now().Sub(start)})
+	}
+	return err
+}
+
+// observerError sends the error to the observer errors channel, dropping it if
+// the channel is full.
+func (b *bus) observerError(err error) {
+	select {
+	case b.observerErrors <- err:
+	default:
+	}
+}
+
+// Returns a channel that receives the errors of observers, such as timeouts
+// and panics. The channel is buffered; errors are dropped while it is full, so
+// it must be drained for errors to keep being delivered.
+func (b *bus) ObserverErrors() <-chan error {
+	return b.observerErrors
+}
+
+// matchedSubscription is a subscription that matched an event, along with the
+// config snapshot it matched with and the first of its matchers that did.
+type matchedSubscription struct {
+	s *subscription
+	c *subscriptionConfig
+	m Matcher
+}
+
+// matchedPool holds the scratch buffers used to collect the subscriptions
+// matching each publish.
+var matchedPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]matchedSubscription, 0, 16)
+		return &buf
+	},
+}
+
+// byPriority orders matched subscriptions by descending priority, then by
+// registration order.
+type byPriority []matchedSubscription
+
+func (m byPriority) Len() int      { return len(m) }
+func (m byPriority) Swap(i, j int) { m[i], m[j] = m[j], m[i] }
+func (m byPriority) Less(i, j int) bool {
+	if m[i].c.priority != m[j].c.priority {
+		return m[i].c.priority > m[j].c.priority
+	}
+	return m[i].s.seq.Load() < m[j].s.seq.Load()
+}
+
+// match appends the subscriptions matching the event to matched, in the order
+// their handlers run.
+func (b *bus) match(ctx context.Context, e Event, matched []matchedSubscription) []matchedSubscription {
+	r := b.load()
+	now := b.clock.Now().UnixNano()
+	add := func(s *subscription) {
+		c := s.load()
+		if m, ok := c.matcher(ctx, e.Name, e.Data); ok {
+			s.active.Store(now)
+			matched = append(matched, matchedSubscription{s: s, c: c, m: m})
+		}
+	}
+
+	// Names that can't be map keys can't be looked up, so they fall back to
+	// matching every subscription.
+	var key Stringer = e.Name
+	if b.caseInsensitive {
+		key = noMatch(foldName(e.Name.String()))
+	}
+	if !reflect.TypeOf(key).Comparable() {
+		for _, subs := range r.subscriptions {
+			for _, s := range subs {
+				add(s)
+			}
+		}
+	} else {
+		for _, s := range r.subscriptions[key] {
+			if _, ok := r.scan[s.id]; !ok {
+				add(s)
+			}
+		}
+		for _, s := range r.scan {
+			add(s)
+		}
+	}
+
+	if len(matched) > 1 {
+		sort.Sort(byPriority(matched))
+	}
+
+	matched = b.pickGroupMembers(matched)
+	if e.trace != nil || e.report != nil {
+		b.traceMatches(e, r, matched)
+	}
+	return matched
+}
+
+// traceMatches records the match decision of every subscription in r into the
+// trace and report of the event, from the subscriptions that handle it. The
+// matchers aren't evaluated again, so that they run once per publish.
+func (b *bus) traceMatches(e Event, r *registry, matched []matchedSubscription) {
+	matchers := make(map[string]string, len(matched))
+	for _, m := range matched {
+		matchers[m.s.id] = m.m.String()
+	}
+
+	var trace MatchTrace
+	for _, subs := range r.subscriptions {
+		for _, s := range subs {
+			d := MatchDecision{SubscriptionID: s.id}
+			if m, ok := matchers[s.id]; ok {
+				d.Matched, d.Matcher = true, m
+			}
+			trace = append(trace, d)
+		}
+	}
+	if e.trace != nil {
+		*e.trace = trace
+	}
+	if e.report != nil {
+		e.report.seed(trace)
+	}
+}
+
+// pickGroupMembers keeps, of the matched subscriptions of each group, only the
+// one whose turn it is. groupTurns counts the events of each group in an
+// *atomic.Uint64.
+func (b *bus) pickGroupMembers(matched []matchedSubscription) []matchedSubscription {
+	var members map[string][]int
+	for i, m := range matched {
+		if m.s.group == "" {
+			continue
+		}
+		if members == nil {
+			members = make(map[string][]int)
+		}
+		members[m.s.group] = append(members[m.s.group], i)
+	}
+	if members == nil {
+		return matched
+	}
+
+	picked := make(map[string]int, len(members))
+	for group, indexes := range members {
+		turns, _ := b.groupTurns.LoadOrStore(group, new(atomic.Uint64))
+		turn := turns.(*atomic.Uint64).Add(1) - 1
+		picked[group] = indexes[turn%uint64(len(indexes))]
+	}
+
+	kept := matched[:0]
+	for i, m := range matched {
+		if m.s.group == "" || picked[m.s.group] == i {
+			kept = append(kept, m)
+		}
+	}
+	// Don't hold on to the dropped subscriptions in the pooled buffer.
+	for i := len(kept); i < len(matched); i++ {
+		matched[i] = matchedSubscription{}
+	}
+	return kept
 }
 
 func (b *bus) publishToSubscriptions(ctx context.Context, e Event) error {
+	buf := matchedPool.Get().(*[]matchedSubscription)
+	matched := b.match(ctx, e, (*buf)[:0])
+	defer func() {
+		// Don't hold on to subscriptions while the buffer sits in the pool.
+		for i := range matched {
+			matched[i] = matchedSubscription{}
+		}
+		*buf = matched[:0]
+		matchedPool.Put(buf)
+	}()
+
+	if b.concurrentSubscribers && !b.deterministic {
+		return b.runSubscriptionsConcurrently(ctx, e, matched)
+	}
+
 	var errs Errors
-	for _, subs := range b.subscriptions {
+	for _, m := range matched {
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
 
-		for _, s := range subs {
-			if !s.Match(e.Name, e.Data) {
-				continue
-			}
+		if err := b.runSubscription(ctx, e, m, &errs); err != nil {
+			return err
+		}
+	}
 
-			for _, fn := range s.funcs {
-				err := doWithTimeout(ctx, e.handlerTimeout, func(ctx context.Context) error {
-					return fn(ctx, e.Name, e.Data)
-				})
-				if err != nil {
-					if b.continueOnError {
-						errs = append(errs, fmt.Errorf("subscription error; subscription: %v, event: %v: %w", s, e, err))
-						continue
-					}
-					return err
-				}
-			}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// runSubscriptionsConcurrently runs the matched subscriptions on their own
+// goroutines, at most b.concurrency at a time. Without continueOnError, the
+// first error cancels the context of the other subscriptions.
+func (b *bus) runSubscriptionsConcurrently(ctx context.Context, e Event, matched []matchedSubscription) error {
+	g, gctx := errgroup.WithContext(ctx)
+	if b.concurrency > 0 {
+		g.SetLimit(int(b.concurrency))
+	}
+
+	var mu sync.Mutex
+	var errs Errors
+	for _, m := range matched {
+		if gctx.Err() != nil {
+			break
 		}
+
+		m := m
+		g.Go(func() error {
+			var serrs Errors
+			if err := b.runSubscription(gctx, e, m, &serrs); err != nil {
+				return err
+			}
+			if len(serrs) > 0 {
+				mu.Lock()
+				errs = append(errs, serrs...)
+				mu.Unlock()
+			}
+			return nil
+		})
 	}
 
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
 	if len(errs) > 0 {
 		return errs
 	}
@@ -139,9 +1034,177 @@ func (b *bus) publishToSubscriptions(ctx context.Context, e Event) error {
 	return nil
 }
 
+// runSubscription runs the handlers of a matched subscription, and removes it
+// afterwards if it is a once subscription that completed.
+func (b *bus) runSubscription(ctx context.Context, e Event, m matchedSubscription, errs *Errors) error {
+	if b.warnEmpty && len(m.c.funcs) == 0 {
+		b.warn(ctx, "matched subscription has no handlers", "subscription", m.s.id, "event", e.ID, "name", e.Name.String())
+	}
+
+	// Only one publish may run a once subscription at a time. A once
+	// subscription without handlers yet isn't used up by an event.
+	once := m.c.once && len(m.c.funcs) > 0
+	if once && !m.s.fired.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	b.log(ctx, "subscription invoked", "subscription", m.s.id, "event", e.ID, "name", e.Name.String())
+	// The handlers see the event as transformed by the subscription, while
+	// everything else keeps seeing the original.
+	in := e
+	if m.c.transform != nil {
+		in.Name, in.Data = m.c.transform(e.Name, e.Data)
+	}
+	start, n := b.clock.Now(), len(*errs)
+	failed, err := b.runHandlers(ctx, e, in, m, errs)
+	serr := err
+	if serr == nil {
+		// With continueOnError, the subscription's errors were collected.
+		serr = joinErrors((*errs)[n:]...)
+	}
+	for _, fn := range m.c.finally {
+		fn(ctx, in.Name, in.Data, serr)
+	}
+	if e.report != nil {
+		e.report.subscription(SubscriptionResult{SubscriptionID: m.s.id, Matched: true, Err: serr, Duration: b.clock.Now().Sub(start)})
+	}
+	if serr != nil {
+		b.deadLetter(ctx, e, serr)
+	}
+	if once {
+		if failed {
+			// The subscription didn't complete, so it stays for the next event.
+			m.s.fired.Store(false)
+		} else {
+			b.Unsubscribe(m.s)
+		}
+	}
+	return err
+}
+
+// runHandlers runs the handlers of a matched subscription in order. It reports
+// whether any handler failed, and returns the error that aborts the publish,
+// if any; with continueOnError, errors are appended to errs instead. Isolated
+// handlers all run, and their errors are joined. The handlers are passed in,
+// the event as the subscription transformed it.
+func (b *bus) runHandlers(ctx context.Context, e, in Event, m matchedSubscription, errs *Errors) (bool, error) {
+	failed := false
+	var isolated []error
+	for _, fn := range m.c.funcs {
+		var err error
+		start := b.clock.Now()
+		if b.tracer != nil {
+			hctx, end := b.startSpan(ctx, "handler", eventIDKey.String(e.ID), subscriptionIDKey.String(m.s.id))
+			err = b.retry(hctx, in, m.c.retry, fn)
+			end(err)
+		} else {
+			err = b.retry(ctx, in, m.c.retry, fn)
+		}
+		b.metrics.ObserveHandlerDuration(e.Name.String(), m.s.id, b.clock.Now().Sub(start))
+		b.countError(e, err)
+		if err != nil {
+			failed = true
+			b.logErr(ctx, "handler failed", "subscription", m.s.id, "event", e.ID, "name", e.Name.String(), "error", err)
+			if b.captureOnError != nil {
+				b.captureOnError(e)
+			}
+
+			if b.continueOnError {
+				*errs = append(*errs, fmt.Errorf("subscription error; subscription: %s, event: %v: %w", m.s.Describe(), e, err))
+				continue
+			}
+			if m.c.isolated {
+				isolated = append(isolated, err)
+				continue
+			}
+			return true, err
+		}
+	}
+
+	return failed, joinErrors(isolated...)
+}
+
+// invoke runs a subscription handler with the event's handler timeout, or
+// directly on the calling goroutine for inline events. A panicking handler
+// fails with a *PanicError.
+func (b *bus) invoke(ctx context.Context, e Event, fn Handler) error {
+	fn = b.recoverHandler(e, fn)
+	if e.inline {
+		return fn(ctx, e.Name, e.Data)
+	}
+
+	if !b.captureStack {
+		return doWithTimeout(ctx, e.handlerTimeout, ErrHandlerTimeout, func(ctx context.Context) error {
+			return fn(ctx, e.Name, e.Data)
+		})
+	}
+
+	gid := make(chan uint64, 1)
+	err := doWithTimeout(ctx, e.handlerTimeout, ErrHandlerTimeout, func(ctx context.Context) error {
+		gid <- goroutineID()
+		return fn(ctx, e.Name, e.Data)
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		// The handler keeps running after a timeout, so its stack shows where
+		// it is stuck.
+		select {
+		case id := <-gid:
+			if stack := goroutineStack(id); stack != nil {
+				b.handleError(ctx, &StackError{Err: err, Stack: stack})
+			}
+		default:
+		}
+	}
+
+	return err
+}
+
+// recoverHandler returns fn with a panic turned into a *PanicError, so that it
+// fails like any other handler instead of taking down the program.
+func (b *bus) recoverHandler(e Event, fn Handler) Handler {
+	return func(ctx context.Context, name Stringer, data interface{}) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = b.recovered(ctx, e, "handler panicked", r)
+			}
+		}()
+
+		return fn(ctx, name, data)
+	}
+}
+
+// recovered reports a panic recovered from a handler or observer of the event,
+// logging it with msg, and returns it as an error. Every panic is reported
+// once to the error handler and once to the panic handler, wherever it
+// happened; the returned error then takes the usual path of the failed
+// handler or observer.
+func (b *bus) recovered(ctx context.Context, e Event, msg string, r interface{}) *PanicError {
+	perr := newPanicError(r)
+	b.logErr(ctx, msg, "event", e.ID, "name", e.Name.String(), "error", perr)
+	b.handleError(ctx, perr)
+	if b.panicHandler != nil {
+		b.panicHandler(perr.Value, perr.Stack)
+	}
+	return perr
+}
+
+// handleError reports an error that can't be returned to the publisher to the
+// error handler, if one is configured.
+func (b *bus) handleError(ctx context.Context, err error) {
+	if b.errorHandler != nil {
+		b.errorHandler(ctx, err)
+	}
+}
+
 // Adds an observer. Observers are notified of all published events, and are
 // executed in parallel.
 func (b *bus) AddObserver(o observer, opts ...observerOpt) string {
+	return b.AddErrorObserver(observerAdapter{o}, opts...)
+}
+
+// Adds an observer that can fail. Its errors are returned by the publish, and
+// also sent to ObserverErrors.
+func (b *bus) AddErrorObserver(o ErrorObserver, opts ...observerOpt) string {
 	id := id.New()
 
 	options := observerOptions{}
@@ -149,21 +1212,25 @@ func (b *bus) AddObserver(o observer, opts ...observerOpt) string {
 		opt(&options)
 	}
 
-	b.observers[id] = observerWithOptions{
-		observer: o,
-		opts:     options,
-	}
+	b.update(func(r *registry) {
+		r.addObserver(observerWithOptions{
+			ErrorObserver: o,
+			id:            id,
+			seq:           b.seq.Add(1),
+			opts:          options,
+		})
+	})
 
 	return id
 }
 
 // Removes an observer.
 func (b *bus) RemoveObserver(id string) bool {
-	if _, ok := b.observers[id]; ok {
-		delete(b.observers, id)
-		return true
-	}
-	return false
+	removed := false
+	b.update(func(r *registry) {
+		removed = r.removeObserver(id)
+	})
+	return removed
 }
 
 // Waits for all published events to finish processing.
@@ -172,10 +1239,9 @@ func (b *bus) Flush(ctx context.Context) {
 		return
 	}
 	done := make(chan struct{})
-	defer close(done)
 	go func() {
 		b.wg.Wait()
-		done <- struct{}{}
+		close(done)
 	}()
 
 	select {
@@ -190,11 +1256,10 @@ func (b *bus) Wait(ctx context.Context) {
 		return
 	}
 	done := make(chan struct{})
-	defer close(done)
 	go func() {
 		<-b.close
 		b.Flush(ctx)
-		done <- struct{}{}
+		close(done)
 	}()
 
 	select {
@@ -203,12 +1268,61 @@ func (b *bus) Wait(ctx context.Context) {
 	}
 }
 
-// Signals the bus to close.
+// Signals the bus to close. It is safe to call more than once, and from
+// multiple goroutines. With WithCloseDrainTimeoutBusOpt, it first waits for
+// the queued events to be handled, up to the timeout.
 func (b *bus) Close() {
-	if b.closed() {
-		return
+	b.closeOnce.Do(func() {
+		close(b.close)
+		if b.queue != nil {
+			b.queue.close()
+			if b.closeDrainTimeout > 0 {
+				b.drainQueue(b.closeDrainTimeout)
+			}
+		}
+		b.log(context.Background(), "bus closed")
+	})
+}
+
+// drainQueue waits up to d for the queue workers to finish the publishes
+// queued, then sends the ones they haven't started to the dead-letter function.
+func (b *bus) drainQueue(d time.Duration) {
+	t := b.clock.NewTicker(d)
+	defer t.Stop()
+
+	select {
+	case <-b.queue.done:
+	case <-t.C():
+		b.queue.drop()
 	}
-	close(b.close)
+}
+
+// cancelOnClosed returns a context that is canceled with ErrBusClosed as its
+// cause when the bus closes. The returned function releases it.
+func (b *bus) cancelOnClosed(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-b.close:
+			cancel(ErrBusClosed)
+		case <-stop:
+		}
+	}()
+
+	return ctx, func() {
+		close(stop)
+		cancel(nil)
+	}
+}
+
+// Closes the bus and waits for the publishes in flight to finish. Returns the
+// error of ctx if it is done before they finish, in which case they keep
+// running.
+func (b *bus) Shutdown(ctx context.Context) error {
+	b.Close()
+	b.Flush(ctx)
+	return ctx.Err()
 }
 
 func (b *bus) closed() bool {
@@ -3,6 +3,11 @@ package eventbus_test
 import (
 	"context"
 	"errors"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -14,6 +19,8 @@ type (
 	ConstantMatcher struct {
 		value bool
 	}
+	observerFunc      func(context.Context, eventbus.Stringer, interface{})
+	errorObserverFunc func(context.Context, eventbus.Stringer, interface{}) error
 )
 
 func (e EventName) String() string {
@@ -28,6 +35,14 @@ func (ConstantMatcher) String() string {
 	return "ConstantMatcher"
 }
 
+func (f observerFunc) Observe(ctx context.Context, name eventbus.Stringer, data interface{}) {
+	f(ctx, name, data)
+}
+
+func (f errorObserverFunc) Observe(ctx context.Context, name eventbus.Stringer, data interface{}) error {
+	return f(ctx, name, data)
+}
+
 var testEvent = EventName("test")
 
 func TestOn_EventPublished_CallsDo(t *testing.T) {
@@ -282,7 +297,8 @@ func TestPublish_WithHandlerTimeoutOption_FailsAfterTimeout(t *testing.T) {
 		return nil
 	})
 
-	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithHandlerTimeoutEventOpt(10*time.Millisecond)); err == nil {
+	err := bus.Publish(ctx, testEvent, nil, eventbus.WithHandlerTimeoutEventOpt(10*time.Millisecond))
+	if !errors.Is(err, eventbus.ErrHandlerTimeout) || errors.Is(err, eventbus.ErrPublishTimeout) {
 		t.Error("expected ErrHandlerTimeout error", err)
 	}
 }
@@ -320,7 +336,8 @@ func TestPublish_WithPublishTimeoutOption_FailsIfTimeExceedsTimeout(t *testing.T
 		return nil
 	})
 
-	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithPublishTimeoutEventOpt(10*time.Millisecond)); err == nil {
+	err := bus.Publish(ctx, testEvent, nil, eventbus.WithPublishTimeoutEventOpt(10*time.Millisecond))
+	if !errors.Is(err, eventbus.ErrPublishTimeout) || errors.Is(err, eventbus.ErrHandlerTimeout) {
 		t.Error("expected ErrPublishTimeout error", err)
 	}
 }
@@ -350,7 +367,2496 @@ func TestPublish_WithPublishTimeoutOption_FailsIfOverallTimeExceedsTimeout(t *te
 		return nil
 	})
 
-	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithPublishTimeoutEventOpt(15*time.Millisecond)); err == nil {
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithPublishTimeoutEventOpt(15*time.Millisecond)); !errors.Is(err, eventbus.ErrPublishTimeout) {
 		t.Error("expected ErrPublishTimeout error", err)
 	}
 }
+
+func TestPublish_ConcurrentWithRegistration_DoesNotRace(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var called int64
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		atomic.AddInt64(&called, 1)
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				if err := bus.Publish(ctx, testEvent, nil); err != nil {
+					t.Error("expected no error", err)
+				}
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 20; j++ {
+			bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+				return nil
+			})
+			id := bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {}))
+			bus.RemoveObserver(id)
+		}
+	}()
+
+	wg.Wait()
+	bus.Flush(ctx)
+
+	if atomic.LoadInt64(&called) != 8*200 {
+		t.Error("expected Do to be called for every publish", atomic.LoadInt64(&called))
+	}
+}
+
+func BenchmarkPublish_Parallel(b *testing.B) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		return nil
+	})
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = bus.Publish(ctx, testEvent, nil)
+		}
+	})
+}
+
+func TestPublish_WithObserverBatchSize_NotifiesAllObservers(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithMaxConcurrencyBusOpt(4), eventbus.WithObserverBatchSizeBusOpt(3))
+
+	var wg sync.WaitGroup
+	var notified int64
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+			defer wg.Done()
+			atomic.AddInt64(&notified, 1)
+		}))
+	}
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	wg.Wait()
+
+	if atomic.LoadInt64(&notified) != 10 {
+		t.Error("expected all observers to be notified", atomic.LoadInt64(&notified))
+	}
+}
+
+func BenchmarkPublish_1000Observers_PerObserverAcquire(b *testing.B) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithMaxConcurrencyBusOpt(256))
+	for i := 0; i < 1000; i++ {
+		bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {}))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = bus.Publish(ctx, testEvent, nil)
+	}
+}
+
+func BenchmarkPublish_1000Observers_BatchedAcquire(b *testing.B) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithMaxConcurrencyBusOpt(256), eventbus.WithObserverBatchSizeBusOpt(64))
+	for i := 0; i < 1000; i++ {
+		bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {}))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = bus.Publish(ctx, testEvent, nil)
+	}
+}
+
+func TestPublish_WithUnlimitedConcurrency_RunsAndTracksAllObservers(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithUnlimitedConcurrencyBusOpt())
+
+	var notified int64
+	for i := 0; i < 100; i++ {
+		bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt64(&notified, 1)
+		}))
+	}
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	bus.Flush(ctx)
+
+	if atomic.LoadInt64(&notified) != 100 {
+		t.Error("expected Flush to wait for all observers", atomic.LoadInt64(&notified))
+	}
+}
+
+func BenchmarkPublish_1000Observers_UnlimitedConcurrency(b *testing.B) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithUnlimitedConcurrencyBusOpt())
+	for i := 0; i < 1000; i++ {
+		bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {}))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = bus.Publish(ctx, testEvent, nil)
+	}
+}
+
+func TestPublish_FromWithinHandler_CallsAllMatchingSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	nested := EventName("nested")
+
+	var called []string
+	bus.On(testEvent).Do(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called = append(called, "outer")
+		return bus.Publish(ctx, nested, nil)
+	})
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called = append(called, "outer")
+		return nil
+	})
+	for i := 0; i < 3; i++ {
+		bus.On(nested).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+			called = append(called, "nested")
+			return nil
+		})
+	}
+
+	for i := 0; i < 2; i++ {
+		called = nil
+		if err := bus.Publish(ctx, testEvent, nil); err != nil {
+			t.Error("expected no error", err)
+		}
+
+		if len(called) != 5 {
+			t.Error("expected Do to be called 5 times", called)
+		}
+	}
+}
+
+func BenchmarkPublish_100MatchingSubscriptions(b *testing.B) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	for i := 0; i < 100; i++ {
+		bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+			return nil
+		})
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = bus.Publish(ctx, testEvent, nil)
+	}
+}
+
+func TestPublish_WithSaturatedObservers_StartsHandlersWithoutWaiting(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithMaxConcurrencyBusOpt(1))
+
+	handlerStarted := make(chan struct{})
+	observerStarted := make(chan struct{})
+	var once sync.Once
+	for i := 0; i < 2; i++ {
+		bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+			once.Do(func() { close(observerStarted) })
+			select {
+			case <-handlerStarted:
+			case <-time.After(time.Second):
+				t.Error("expected handler to start while observer is running")
+			}
+		}))
+	}
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		close(handlerStarted)
+		select {
+		case <-observerStarted:
+		case <-time.After(time.Second):
+			t.Error("expected observer to start while handler is running")
+		}
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	bus.Flush(ctx)
+}
+
+func TestPublish_WithPublishHook_CallsHookAroundDispatch(t *testing.T) {
+	ctx := context.Background()
+	var called []string
+	var hookEvent eventbus.Event
+	var hookErr error
+	bus := eventbus.New(eventbus.WithPublishHookBusOpt(func(e eventbus.Event) func(error) {
+		called = append(called, "pre")
+		hookEvent = e
+		return func(err error) {
+			called = append(called, "post")
+			hookErr = err
+		}
+	}))
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called = append(called, "handler")
+		return errors.New("some error")
+	})
+
+	err := bus.Publish(ctx, testEvent, "data")
+	if err == nil || err.Error() != "some error" {
+		t.Error("expected error", err)
+	}
+
+	if len(called) != 3 || called[0] != "pre" || called[1] != "handler" || called[2] != "post" {
+		t.Error("expected hook to be called around dispatch", called)
+	}
+	if hookEvent.Name != testEvent || hookEvent.Data != "data" {
+		t.Error("expected hook to receive the event", hookEvent)
+	}
+	if hookErr != err {
+		t.Error("expected post hook to receive the publish error", hookErr)
+	}
+}
+
+func TestPublish_WithSingleFlight_SharesConcurrentDispatch(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithSingleFlightBusOpt(func(e eventbus.Event) string {
+		return e.Name.String()
+	}))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var called int64
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		if atomic.AddInt64(&called, 1) == 1 {
+			close(started)
+		}
+		<-release
+		return errors.New("some error")
+	})
+
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		errs[0] = bus.Publish(ctx, testEvent, nil)
+	}()
+	<-started
+	go func() {
+		defer wg.Done()
+		errs[1] = bus.Publish(ctx, testEvent, nil)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if atomic.LoadInt64(&called) != 1 {
+		t.Error("expected Do to be called once", atomic.LoadInt64(&called))
+	}
+	if errs[0] == nil || errs[0] != errs[1] {
+		t.Error("expected both publishes to return the same error", errs)
+	}
+}
+
+func TestPublish_WithCaptureStackOnTimeout_ReportsHandlerStack(t *testing.T) {
+	ctx := context.Background()
+	var reported []error
+	bus := eventbus.New(
+		eventbus.WithCaptureStackOnTimeoutBusOpt(),
+		eventbus.WithErrorHandlerBusOpt(func(_ context.Context, err error) {
+			reported = append(reported, err)
+		}),
+	)
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+
+	err := bus.Publish(ctx, testEvent, nil, eventbus.WithHandlerTimeoutEventOpt(10*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected timeout error", err)
+	}
+
+	if len(reported) != 1 {
+		t.Fatal("expected one error to be reported", reported)
+	}
+	var stackErr *eventbus.StackError
+	if !errors.As(reported[0], &stackErr) {
+		t.Fatal("expected a StackError", reported[0])
+	}
+	if !errors.Is(stackErr, context.DeadlineExceeded) {
+		t.Error("expected StackError to wrap the timeout", stackErr.Err)
+	}
+	if !strings.Contains(string(stackErr.Stack), "time.Sleep") {
+		t.Error("expected stack of the sleeping handler", string(stackErr.Stack))
+	}
+}
+
+func TestPublish_WithoutCaptureStackOnTimeout_ReportsNothing(t *testing.T) {
+	ctx := context.Background()
+	reported := false
+	bus := eventbus.New(eventbus.WithErrorHandlerBusOpt(func(context.Context, error) {
+		reported = true
+	}))
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithHandlerTimeoutEventOpt(10*time.Millisecond)); err == nil {
+		t.Error("expected timeout error", err)
+	}
+
+	if reported {
+		t.Error("expected no error to be reported")
+	}
+}
+
+func TestOn_WithCaseInsensitiveNames_MatchesDifferentCase(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithCaseInsensitiveNamesBusOpt())
+	called := false
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called = true
+		return nil
+	})
+
+	if err := bus.Publish(ctx, EventName("Test"), nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if !called {
+		t.Error("expected Do to be called")
+	}
+}
+
+func TestOn_WithCaseInsensitiveNames_MatchesUnicodeFolding(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithCaseInsensitiveNamesBusOpt())
+	called := 0
+	// The long s folds to s, though it isn't lower-cased to it.
+	bus.On(EventName("ſtart")).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called++
+		return nil
+	})
+
+	for _, name := range []EventName{"START", "start", "ſtart", "stop"} {
+		if err := bus.Publish(ctx, name, nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if called != 3 {
+		t.Error("expected every spelling of the name to be handled", called)
+	}
+}
+
+func TestOn_WithoutCaseInsensitiveNames_DoesNotMatchDifferentCase(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	called := false
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called = true
+		return nil
+	})
+
+	if err := bus.Publish(ctx, EventName("Test"), nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if called {
+		t.Error("expected Do to not be called")
+	}
+}
+
+func TestInFlight_CancelSlowPublish_RemovesPublish(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	bus.On(testEvent).Do(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	published := make(chan error)
+	go func() {
+		published <- bus.Publish(ctx, testEvent, nil)
+	}()
+
+	var inFlight []eventbus.InFlightPublish
+	for i := 0; i < 100 && len(inFlight) == 0; i++ {
+		time.Sleep(time.Millisecond)
+		inFlight = bus.InFlight()
+	}
+	if len(inFlight) != 1 {
+		t.Fatal("expected one publish in flight", inFlight)
+	}
+	if inFlight[0].Name != testEvent || inFlight[0].EventID == "" || inFlight[0].Started.IsZero() {
+		t.Error("expected publish metadata", inFlight[0])
+	}
+
+	inFlight[0].Cancel()
+	if err := <-published; !errors.Is(err, context.Canceled) {
+		t.Error("expected context.Canceled error", err)
+	}
+
+	if len(bus.InFlight()) != 0 {
+		t.Error("expected no publish in flight", bus.InFlight())
+	}
+}
+
+func TestInFlight_CancelPublish_CancelsObserver(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	started := make(chan struct{})
+	observed := make(chan error, 1)
+	bus.AddObserver(observerFunc(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) {
+		close(started)
+		select {
+		case <-ctx.Done():
+			observed <- ctx.Err()
+		case <-time.After(time.Second):
+			observed <- nil
+		}
+	}))
+
+	go func() {
+		_ = bus.Publish(ctx, testEvent, nil)
+	}()
+	<-started
+	inFlight := bus.InFlight()
+	if len(inFlight) != 1 {
+		t.Fatal("expected one publish in flight", inFlight)
+	}
+	inFlight[0].Cancel()
+
+	if err := <-observed; !errors.Is(err, context.Canceled) {
+		t.Error("expected the observer context to be canceled", err)
+	}
+	bus.Flush(ctx)
+}
+
+func TestPublishRequire_WithFewerSubscribers_ReturnsErrorWithoutDispatching(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	called := false
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called = true
+		return nil
+	})
+
+	if err := bus.PublishRequire(ctx, testEvent, nil, 2); !errors.Is(err, eventbus.ErrInsufficientSubscribers) {
+		t.Error("expected ErrInsufficientSubscribers error", err)
+	}
+
+	if called {
+		t.Error("expected Do to not be called")
+	}
+}
+
+func TestPublishRequire_WithEnoughSubscribers_Publishes(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	called := 0
+	for i := 0; i < 2; i++ {
+		bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+			called++
+			return nil
+		})
+	}
+	bus.On(EventName("other")).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called++
+		return nil
+	})
+
+	if err := bus.PublishRequire(ctx, testEvent, nil, 2); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if called != 2 {
+		t.Error("expected Do to be called 2 times", called)
+	}
+}
+
+// goroutineID returns the ID of the calling goroutine.
+func goroutineID() string {
+	buf := make([]byte, 64)
+	return strings.Fields(string(buf[:runtime.Stack(buf, false)]))[1]
+}
+
+func TestPublish_WithInlineHandlers_RunsHandlersOnPublishingGoroutine(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var handlerGoroutine string
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		handlerGoroutine = goroutineID()
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithInlineHandlersEventOpt()); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if handlerGoroutine != goroutineID() {
+		t.Error("expected handler to run on the publishing goroutine", handlerGoroutine, goroutineID())
+	}
+}
+
+func TestPublish_WithInlineHandlers_IgnoresTimeouts(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+
+	err := bus.Publish(ctx, testEvent, nil,
+		eventbus.WithInlineHandlersEventOpt(),
+		eventbus.WithHandlerTimeoutEventOpt(5*time.Millisecond),
+		eventbus.WithPublishTimeoutEventOpt(5*time.Millisecond),
+	)
+	if err != nil {
+		t.Error("expected no error", err)
+	}
+}
+
+func BenchmarkPublish_DefaultHandlers(b *testing.B) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		return nil
+	})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = bus.Publish(ctx, testEvent, nil)
+	}
+}
+
+func BenchmarkPublish_InlineHandlers(b *testing.B) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		return nil
+	})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = bus.Publish(ctx, testEvent, nil, eventbus.WithInlineHandlersEventOpt())
+	}
+}
+
+func TestPublish_ObserverPanics_ReportsPanicAndKeepsObserver(t *testing.T) {
+	ctx := context.Background()
+	var reported []error
+	var mu sync.Mutex
+	bus := eventbus.New(eventbus.WithErrorHandlerBusOpt(func(_ context.Context, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, err)
+	}))
+
+	var notified []interface{}
+	bus.AddObserver(observerFunc(func(_ context.Context, _ eventbus.Stringer, data interface{}) {
+		if data == "panic" {
+			panic("observer failed")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		notified = append(notified, data)
+	}))
+
+	var perr *eventbus.PanicError
+	if err := bus.Publish(ctx, testEvent, "panic"); !errors.As(err, &perr) {
+		t.Error("expected panic error", err)
+	}
+	bus.Flush(ctx)
+	if err := bus.Publish(ctx, testEvent, "ok"); err != nil {
+		t.Error("expected no error", err)
+	}
+	bus.Flush(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(notified) != 1 || notified[0] != "ok" {
+		t.Error("expected observer to be notified after panicking", notified)
+	}
+	var panicErr *eventbus.PanicError
+	if len(reported) != 1 || !errors.As(reported[0], &panicErr) || panicErr.Value != "observer failed" {
+		t.Error("expected panic to be reported", reported)
+	}
+}
+
+func TestPublish_WithRejectNilDataAndNilData_ReturnsError(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithRejectNilDataBusOpt())
+	called := false
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called = true
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); err != eventbus.ErrNilData {
+		t.Error("expected ErrNilData error", err)
+	}
+
+	if called {
+		t.Error("expected Do to not be called")
+	}
+}
+
+func TestPublish_WithRejectNilDataAndData_CallsDo(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithRejectNilDataBusOpt())
+	called := false
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called = true
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, "data"); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if !called {
+		t.Error("expected Do to be called")
+	}
+}
+
+func TestPublish_WithContextKeys_CopiesOnlyListedKeysToObservers(t *testing.T) {
+	type contextKey string
+	listed, unlisted := contextKey("listed"), contextKey("unlisted")
+	ctx := context.WithValue(context.Background(), listed, "trace")
+	ctx = context.WithValue(ctx, unlisted, "secret")
+	bus := eventbus.New(eventbus.WithContextKeysBusOpt(listed))
+
+	var listedValue, unlistedValue interface{}
+	bus.AddObserver(observerFunc(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) {
+		listedValue, unlistedValue = ctx.Value(listed), ctx.Value(unlisted)
+	}))
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	bus.Flush(ctx)
+
+	if listedValue != "trace" {
+		t.Error("expected listed key to be copied", listedValue)
+	}
+	if unlistedValue != nil {
+		t.Error("expected unlisted key to not be copied", unlistedValue)
+	}
+}
+
+func TestPublish_WithContextKeys_CopiesOnlyListedKeysToAsyncHandlers(t *testing.T) {
+	type contextKey string
+	listed, unlisted := contextKey("listed"), contextKey("unlisted")
+	ctx := context.WithValue(context.Background(), listed, "trace")
+	ctx = context.WithValue(ctx, unlisted, "secret")
+	bus := eventbus.New(eventbus.WithContextKeysBusOpt(listed))
+
+	var listedValue, unlistedValue interface{}
+	bus.On(testEvent).Do(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) error {
+		listedValue, unlistedValue = ctx.Value(listed), ctx.Value(unlisted)
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithAsyncEventOpt()); err != nil {
+		t.Error("expected no error", err)
+	}
+	bus.Flush(ctx)
+
+	if listedValue != "trace" {
+		t.Error("expected listed key to be copied", listedValue)
+	}
+	if unlistedValue != nil {
+		t.Error("expected unlisted key to not be copied", unlistedValue)
+	}
+}
+
+func TestPublish_WithoutContextKeys_CopiesAllValuesToObservers(t *testing.T) {
+	type contextKey string
+	key := contextKey("key")
+	ctx := context.WithValue(context.Background(), key, "value")
+	bus := eventbus.New()
+
+	var value interface{}
+	bus.AddObserver(observerFunc(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) {
+		value = ctx.Value(key)
+	}))
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	bus.Flush(ctx)
+
+	if value != "value" {
+		t.Error("expected value to be copied", value)
+	}
+}
+
+func TestObserverErrors_ObserverTimesOut_ReceivesError(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+		time.Sleep(50 * time.Millisecond)
+	}), eventbus.WithTimeoutObserverOpt(10*time.Millisecond))
+
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected timeout error", err)
+	}
+
+	select {
+	case err := <-bus.ObserverErrors():
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Error("expected timeout error", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected observer error")
+	}
+}
+
+func TestObserverErrors_ChannelFull_DropsErrors(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+		panic("observer failed")
+	}))
+
+	for i := 0; i < 100; i++ {
+		var perr *eventbus.PanicError
+		if err := bus.Publish(ctx, testEvent, nil); !errors.As(err, &perr) {
+			t.Error("expected panic error", err)
+		}
+	}
+	bus.Flush(ctx)
+
+	if n := len(bus.ObserverErrors()); n != cap(bus.ObserverErrors()) {
+		t.Error("expected channel to be full without blocking", n)
+	}
+}
+
+func TestPublish_WithCaptureOnErrorAndFailingHandler_CapturesEvent(t *testing.T) {
+	ctx := context.Background()
+	var captured []eventbus.Event
+	bus := eventbus.New(eventbus.WithCaptureOnErrorBusOpt(func(e eventbus.Event) {
+		captured = append(captured, e)
+	}))
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, data interface{}) error {
+		if data == "bad" {
+			return errors.New("some error")
+		}
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, "good"); err != nil {
+		t.Error("expected no error", err)
+	}
+	if len(captured) != 0 {
+		t.Error("expected nothing to be captured for a successful handler", captured)
+	}
+
+	if err := bus.Publish(ctx, testEvent, "bad"); err == nil {
+		t.Error("expected error", err)
+	}
+	if len(captured) != 1 || captured[0].Name != testEvent || captured[0].Data != "bad" || captured[0].ID == "" {
+		t.Error("expected the failing event to be captured", captured)
+	}
+}
+
+func TestPublish_ConcurrentWithSubscribing_DoesNotRace(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+				return nil
+			})
+			bus.When(ConstantMatcher{true})
+		}()
+		go func() {
+			defer wg.Done()
+			if err := bus.Publish(ctx, testEvent, nil); err != nil {
+				t.Error("expected no error", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var trace eventbus.MatchTrace
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithMatchTraceEventOpt(&trace)); err != nil {
+		t.Error("expected no error", err)
+	}
+	if len(trace) != 100 {
+		t.Error("expected every subscription to be registered", len(trace))
+	}
+}
+
+func TestPublish_HandlerSubscribes_DoesNotDeadlock(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	called := 0
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+			called++
+			return nil
+		})
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithPublishTimeoutEventOpt(time.Second)); err != nil {
+		t.Error("expected no error", err)
+	}
+	if called != 0 {
+		t.Error("expected subscription added during publish to not be called", called)
+	}
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithPublishTimeoutEventOpt(time.Second)); err != nil {
+		t.Error("expected no error", err)
+	}
+	if called != 1 {
+		t.Error("expected subscription added during publish to be called on the next publish", called)
+	}
+}
+
+// waitForGoroutines fails the test if the number of goroutines does not drop
+// back to at most baseline within a second.
+func waitForGoroutines(t *testing.T, baseline int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > baseline {
+		if time.Now().After(deadline) {
+			t.Error("expected goroutines to exit", runtime.NumGoroutine(), baseline)
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPublish_FastHandlersWithTimeouts_LeavesNoGoroutines(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {}), eventbus.WithTimeoutObserverOpt(time.Second))
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error { return nil })
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error { return nil })
+
+	baseline := runtime.NumGoroutine()
+	for i := 0; i < 100; i++ {
+		err := bus.Publish(ctx, testEvent, nil,
+			eventbus.WithPublishTimeoutEventOpt(time.Second),
+			eventbus.WithHandlerTimeoutEventOpt(time.Second))
+		if err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+	bus.Flush(ctx)
+
+	waitForGoroutines(t, baseline)
+}
+
+func TestPublish_WithoutObservers_StartsNoGoroutines(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var during int
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		during = runtime.NumGoroutine()
+		return nil
+	})
+
+	baseline := runtime.NumGoroutine()
+	// Inline, the handler runs on the publishing goroutine.
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithInlineHandlersEventOpt()); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if during != baseline {
+		t.Error("expected the handler to run without other goroutines", during, baseline)
+	}
+}
+
+func TestPublish_HandlerOutlivesTimeout_LeavesNoGoroutines(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	release := make(chan struct{})
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		<-release
+		return nil
+	})
+
+	baseline := runtime.NumGoroutine()
+	err := bus.Publish(ctx, testEvent, nil, eventbus.WithHandlerTimeoutEventOpt(10*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected deadline exceeded error", err)
+	}
+	close(release)
+	bus.Flush(ctx)
+
+	waitForGoroutines(t, baseline)
+}
+
+func TestFlush_ContextCanceledBeforeObserversFinish_DoesNotPanic(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	release := make(chan struct{})
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+		<-release
+	}))
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithAsyncEventOpt()); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	flushCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	bus.Flush(flushCtx)
+
+	close(release)
+	bus.Flush(ctx)
+	bus.Close()
+	bus.Wait(ctx)
+}
+
+func TestPublishStartFromContext_InHandler_ReturnsPublishStart(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var start time.Time
+	var ok bool
+	bus.On(testEvent).Do(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) error {
+		start, ok = eventbus.PublishStartFromContext(ctx)
+		return nil
+	})
+
+	before := time.Now()
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if !ok {
+		t.Error("expected start time to be in the handler context")
+	}
+	if start.Before(before) || time.Since(start) > time.Second {
+		t.Error("expected start time to be close to the publish", start, before)
+	}
+}
+
+func TestPublishStartFromContext_OutsidePublish_ReturnsFalse(t *testing.T) {
+	if _, ok := eventbus.PublishStartFromContext(context.Background()); ok {
+		t.Error("expected no start time outside a publish")
+	}
+}
+
+func TestOnAny_EventsPublished_CalledForEveryName(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var called []string
+	bus.OnAny(func(_ context.Context, name eventbus.Stringer, _ interface{}) error {
+		called = append(called, name.String())
+		return nil
+	})
+
+	for _, name := range []string{"first", "second", "third"} {
+		if err := bus.Publish(ctx, EventName(name), nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if strings.Join(called, ",") != "first,second,third" {
+		t.Error("expected handler to be called for every event", called)
+	}
+}
+
+func TestOnAny_HandlerFails_PublishFailsFast(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	errFailed := errors.New("failed")
+	bus.OnAny(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		return errFailed
+	})
+	called := false
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called = true
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, errFailed) {
+		t.Error("expected handler error", err)
+	}
+	if called {
+		t.Error("expected later subscription to not be called")
+	}
+}
+
+func TestPublish_WithCriticalObserversFirst_RunsCriticalObserversInOrderBeforeDispatch(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithCriticalObserversFirstBusOpt())
+	var mu sync.Mutex
+	var called []string
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		called = append(called, name)
+	}
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+		time.Sleep(10 * time.Millisecond)
+		record("critical1")
+	}), eventbus.WithCriticalObserverOpt())
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+		record("critical2")
+	}), eventbus.WithCriticalObserverOpt())
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+		record("observer")
+	}))
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		record("subscription")
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	bus.Flush(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(called) != 4 || called[0] != "critical1" || called[1] != "critical2" {
+		t.Error("expected critical observers to run first, in order", called)
+	}
+}
+
+func TestPublish_WithCriticalObserversFirst_CriticalFailureAbortsDispatch(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithCriticalObserversFirstBusOpt())
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+		panic("critical failure")
+	}), eventbus.WithCriticalObserverOpt())
+	var observed atomic.Bool
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+		observed.Store(true)
+	}))
+	called := false
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called = true
+		return nil
+	})
+
+	err := bus.Publish(ctx, testEvent, nil)
+	var perr *eventbus.PanicError
+	if !errors.As(err, &perr) {
+		t.Error("expected critical observer panic to be returned", err)
+	}
+	bus.Flush(ctx)
+
+	if called || observed.Load() {
+		t.Error("expected dispatch to be aborted", called, observed.Load())
+	}
+}
+
+func TestPublish_CriticalObserverWithoutBusOption_RunsAlongsideSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	handled := make(chan struct{})
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+		<-handled
+	}), eventbus.WithCriticalObserverOpt())
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		close(handled)
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithPublishTimeoutEventOpt(time.Second)); err != nil {
+		t.Error("expected no error", err)
+	}
+}
+
+func TestPublish_WithAsyncOption_ReturnsBeforeHandlersFinish(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	release := make(chan struct{})
+	var finished atomic.Bool
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		<-release
+		finished.Store(true)
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithAsyncEventOpt()); err != nil {
+		t.Error("expected no error", err)
+	}
+	if finished.Load() {
+		t.Error("expected publish to return before the handler finished")
+	}
+
+	close(release)
+	bus.Flush(ctx)
+	if !finished.Load() {
+		t.Error("expected flush to wait for the handler")
+	}
+}
+
+func TestPublish_WithAsyncOption_ReportsErrorsToErrorHandler(t *testing.T) {
+	ctx := context.Background()
+	errFailed := errors.New("failed")
+	var mu sync.Mutex
+	var reported []error
+	bus := eventbus.New(eventbus.WithErrorHandlerBusOpt(func(_ context.Context, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		reported = append(reported, err)
+	}))
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		return errFailed
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithAsyncEventOpt()); err != nil {
+		t.Error("expected no error", err)
+	}
+	bus.Flush(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 1 || !errors.Is(reported[0], errFailed) {
+		t.Error("expected handler error to be reported", reported)
+	}
+}
+
+func TestPublish_WithAsyncOption_CallerContextCanceled_StillDispatches(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	bus := eventbus.New()
+	release := make(chan struct{})
+	var finished atomic.Bool
+	bus.On(testEvent).Do(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) error {
+		<-release
+		finished.Store(ctx.Err() == nil)
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithAsyncEventOpt()); err != nil {
+		t.Error("expected no error", err)
+	}
+	cancel()
+	close(release)
+	bus.Flush(context.Background())
+
+	if !finished.Load() {
+		t.Error("expected handler to run with a live context")
+	}
+}
+
+func TestPublish_WithUnhandledEvent_UnroutedEventPublishedUnderName(t *testing.T) {
+	ctx := context.Background()
+	unhandled := EventName("unhandled")
+	bus := eventbus.New(eventbus.WithUnhandledEventBusOpt(unhandled))
+	var received []eventbus.Event
+	bus.On(unhandled).Do(func(_ context.Context, _ eventbus.Stringer, data interface{}) error {
+		received = append(received, data.(eventbus.Event))
+		return nil
+	})
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		return nil
+	})
+
+	if err := bus.Publish(ctx, EventName("unrouted"), "data"); err != nil {
+		t.Error("expected no error", err)
+	}
+	if err := bus.Publish(ctx, testEvent, "data"); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if len(received) != 1 {
+		t.Error("expected only the unrouted event to be redirected", received)
+		return
+	}
+	if received[0].Name.String() != "unrouted" || received[0].Data != "data" {
+		t.Error("expected the original event to be received", received[0])
+	}
+}
+
+func TestPublish_WithUnhandledEventAndNoHandler_DoesNotRecurse(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithUnhandledEventBusOpt(EventName("unhandled")))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- bus.Publish(ctx, testEvent, nil)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error("expected no error", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected publish to return")
+	}
+}
+
+func TestPublish_WithUnhandledEventAndObserver_DoesNotRedirect(t *testing.T) {
+	ctx := context.Background()
+	unhandled := EventName("unhandled")
+	bus := eventbus.New(eventbus.WithUnhandledEventBusOpt(unhandled))
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {}))
+	called := false
+	bus.On(unhandled).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called = true
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	bus.Flush(ctx)
+
+	if called {
+		t.Error("expected observed event to not be redirected")
+	}
+}
+
+func TestPublish_WithUnhandledEventAndObserverMatchingOthers_Redirects(t *testing.T) {
+	ctx := context.Background()
+	unhandled := EventName("unhandled")
+	bus := eventbus.New(eventbus.WithUnhandledEventBusOpt(unhandled))
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {}), eventbus.WithMatcherObserverOpt(eventbus.ExactMatcher(EventName("other"))))
+	called := false
+	bus.On(unhandled).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called = true
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	bus.Flush(ctx)
+
+	if !called {
+		t.Error("expected the event no observer is notified of to be redirected")
+	}
+}
+
+func TestPublish_WithUnhandledEventAndObserversFilteredOut_Redirects(t *testing.T) {
+	ctx := context.Background()
+	unhandled := EventName("unhandled")
+	bus := eventbus.New(eventbus.WithUnhandledEventBusOpt(unhandled), eventbus.WithObserverMatchersBusOpt(eventbus.ExactMatcher(EventName("other"))))
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {}))
+	called := false
+	bus.On(unhandled).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called = true
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	bus.Flush(ctx)
+
+	if !called {
+		t.Error("expected the event no observer is notified of to be redirected")
+	}
+}
+
+func TestPublish_WithUnhandledEventWithoutObservers_Redirects(t *testing.T) {
+	ctx := context.Background()
+	unhandled := EventName("unhandled")
+	bus := eventbus.New(eventbus.WithUnhandledEventBusOpt(unhandled))
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {}))
+	called := false
+	bus.On(unhandled).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called = true
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithoutObserversEventOpt()); err != nil {
+		t.Error("expected no error", err)
+	}
+	bus.Flush(ctx)
+
+	if !called {
+		t.Error("expected the event no observer is notified of to be redirected")
+	}
+}
+
+func TestAddErrorObserver_ObserverFails_ErrorSentToObserverErrors(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	errFailed := errors.New("failed")
+	bus.AddErrorObserver(errorObserverFunc(func(context.Context, eventbus.Stringer, interface{}) error {
+		return errFailed
+	}))
+
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, errFailed) {
+		t.Error("expected observer error", err)
+	}
+
+	select {
+	case err := <-bus.ObserverErrors():
+		if !errors.Is(err, errFailed) {
+			t.Error("expected observer error", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected observer error")
+	}
+}
+
+func TestAddErrorObserver_CriticalObserverFails_PublishReturnsError(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithCriticalObserversFirstBusOpt())
+	errFailed := errors.New("failed")
+	bus.AddErrorObserver(errorObserverFunc(func(context.Context, eventbus.Stringer, interface{}) error {
+		return errFailed
+	}), eventbus.WithCriticalObserverOpt())
+
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, errFailed) {
+		t.Error("expected observer error", err)
+	}
+}
+
+func TestAddErrorObserver_CriticalObserverTimesOut_PublishReturnsError(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithCriticalObserversFirstBusOpt())
+	bus.AddErrorObserver(errorObserverFunc(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) error {
+		<-ctx.Done()
+		return nil
+	}), eventbus.WithCriticalObserverOpt(), eventbus.WithTimeoutObserverOpt(10*time.Millisecond))
+
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected timeout error", err)
+	}
+}
+
+func TestClone_EventPublished_CallsSameHandlers(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithContinueOnErrorBusOpt())
+	var called atomic.Int64
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called.Add(1)
+		return nil
+	})
+	var observed atomic.Int64
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+		observed.Add(1)
+	}))
+
+	clone := bus.Clone()
+	if err := clone.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	clone.Flush(ctx)
+
+	if called.Load() != 1 || observed.Load() != 1 {
+		t.Error("expected clone to call the same handlers and observers", called.Load(), observed.Load())
+	}
+}
+
+func TestClone_Closed_OriginalStillPublishes(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	clone := bus.Clone()
+	clone.Close()
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected original bus to still publish", err)
+	}
+	if err := clone.Publish(ctx, testEvent, nil); !errors.Is(err, eventbus.ErrBusClosed) {
+		t.Error("expected clone to be closed", err)
+	}
+}
+
+func TestClone_SubscriptionsChanged_OtherBusUnaffected(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	called := 0
+	s := bus.On(testEvent)
+	s.Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called++
+		return nil
+	})
+
+	clone := bus.Clone()
+	s.Unsubscribe()
+	clone.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called += 10
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	if called != 0 {
+		t.Error("expected original bus to have no subscriptions", called)
+	}
+	if err := clone.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	if called != 11 {
+		t.Error("expected clone to keep the removed subscription and its own", called)
+	}
+}
+
+func TestPublishMulti_ThreeNames_CallsHandlersOfEachWithSameData(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	names := []eventbus.Stringer{EventName("first"), EventName("second"), EventName("third")}
+	received := map[string]interface{}{}
+	for _, name := range names {
+		bus.On(name).Do(func(_ context.Context, name eventbus.Stringer, data interface{}) error {
+			received[name.String()] = data
+			return nil
+		})
+	}
+
+	if err := bus.PublishMulti(ctx, names, "data"); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if len(received) != 3 || received["first"] != "data" || received["second"] != "data" || received["third"] != "data" {
+		t.Error("expected every name to receive the data", received)
+	}
+}
+
+func TestPublishMulti_SomeFail_PublishesAllAndJoinsErrors(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	errFirst, errThird := errors.New("first"), errors.New("third")
+	called := false
+	bus.On(EventName("first")).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error { return errFirst })
+	bus.On(EventName("second")).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called = true
+		return nil
+	})
+	bus.On(EventName("third")).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error { return errThird })
+
+	err := bus.PublishMulti(ctx, []eventbus.Stringer{EventName("first"), EventName("second"), EventName("third")}, nil)
+	if !errors.Is(err, errFirst) || !errors.Is(err, errThird) {
+		t.Error("expected both errors", err)
+	}
+	if !called {
+		t.Error("expected every name to be published")
+	}
+}
+
+func TestPublishMulti_EachEventHasOwnID(t *testing.T) {
+	ctx := context.Background()
+	var ids []string
+	bus := eventbus.New(eventbus.WithPublishHookBusOpt(func(e eventbus.Event) func(error) {
+		ids = append(ids, e.ID)
+		return nil
+	}))
+
+	if err := bus.PublishMulti(ctx, []eventbus.Stringer{EventName("first"), EventName("second")}, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if len(ids) != 2 || ids[0] == ids[1] {
+		t.Error("expected each event to have its own ID", ids)
+	}
+}
+
+func TestPublish_ObserverOutlivesPublishTimeout_ReturnsTimeoutError(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+
+	err := bus.Publish(ctx, testEvent, nil, eventbus.WithPublishTimeoutEventOpt(10*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected timeout error", err)
+	}
+	bus.Flush(ctx)
+}
+
+func TestPublish_ObserversFinish_PublishWaitsForThem(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var observed atomic.Int64
+	for i := 0; i < 3; i++ {
+		bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+			time.Sleep(5 * time.Millisecond)
+			observed.Add(1)
+		}))
+	}
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if observed.Load() != 3 {
+		t.Error("expected publish to return after every observer", observed.Load())
+	}
+}
+
+func TestPublish_WithContinueOnErrorAndFailingObservers_ReturnsAllErrors(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithContinueOnErrorBusOpt())
+	errFirst, errSecond := errors.New("first"), errors.New("second")
+	bus.AddErrorObserver(errorObserverFunc(func(context.Context, eventbus.Stringer, interface{}) error { return errFirst }))
+	bus.AddErrorObserver(errorObserverFunc(func(context.Context, eventbus.Stringer, interface{}) error { return errSecond }))
+
+	err := bus.Publish(ctx, testEvent, nil)
+	if !errors.Is(err, errFirst) || !errors.Is(err, errSecond) {
+		t.Error("expected both observer errors", err)
+	}
+}
+
+func TestAddObserver_WithMatcher_NotifiedOnlyOfMatchingEvents(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var mu sync.Mutex
+	var names, errs []string
+	bus.AddObserver(observerFunc(func(_ context.Context, name eventbus.Stringer, _ interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		names = append(names, name.String())
+	}), eventbus.WithMatcherObserverOpt(eventbus.WildcardMatcher("order.*")))
+	bus.AddObserver(observerFunc(func(_ context.Context, name eventbus.Stringer, _ interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, name.String())
+	}), eventbus.WithMatcherObserverOpt(eventbus.ErrorMatcher{}))
+
+	publish := func(name string, data interface{}) {
+		if err := bus.Publish(ctx, EventName(name), data); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+	publish("order.created", nil)
+	publish("user.created", nil)
+	publish("user.failed", errors.New("failed"))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(names, ",") != "order.created" {
+		t.Error("expected only order events to be observed", names)
+	}
+	if strings.Join(errs, ",") != "user.failed" {
+		t.Error("expected only error events to be observed", errs)
+	}
+}
+
+func TestPublish_WithObserverMatchers_FiltersEventsForAllObservers(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithObserverMatchersBusOpt(eventbus.PrefixMatcher("order.")))
+	var observed atomic.Int64
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+		observed.Add(1)
+	}))
+
+	for _, name := range []string{"order.created", "user.created"} {
+		if err := bus.Publish(ctx, EventName(name), nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if observed.Load() != 1 {
+		t.Error("expected only events passing the filter to be observed", observed.Load())
+	}
+}
+
+func TestPublish_NonMatchingObservers_DoNotSpawnGoroutines(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	publish := func() {
+		if err := bus.Publish(ctx, testEvent, nil, eventbus.WithInlineHandlersEventOpt()); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+	baseline := testing.AllocsPerRun(100, publish)
+
+	for i := 0; i < 100; i++ {
+		bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+			t.Error("expected observer to not be notified")
+		}), eventbus.WithMatcherObserverOpt(eventbus.PrefixMatcher("other.")))
+	}
+	filtered := testing.AllocsPerRun(100, publish)
+
+	if filtered > baseline+10 {
+		t.Error("expected no allocations per filtered observer", baseline, filtered)
+	}
+}
+
+func TestPublish_ParentContextCanceledDuringHandler_ReturnsCanceledNotTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	bus := eventbus.New()
+	bus.On(testEvent).Do(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) error {
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	err := bus.Publish(ctx, testEvent, nil,
+		eventbus.WithHandlerTimeoutEventOpt(time.Second),
+		eventbus.WithPublishTimeoutEventOpt(time.Second))
+	if !errors.Is(err, context.Canceled) {
+		t.Error("expected canceled error", err)
+	}
+	if errors.Is(err, eventbus.ErrHandlerTimeout) || errors.Is(err, eventbus.ErrPublishTimeout) {
+		t.Error("expected cancellation to not be reported as a timeout", err)
+	}
+}
+
+func TestPublish_ParentDeadlineExceeded_NotReportedAsBusTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	bus := eventbus.New()
+	bus.On(testEvent).Do(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	err := bus.Publish(ctx, testEvent, nil, eventbus.WithHandlerTimeoutEventOpt(time.Second))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected deadline exceeded error", err)
+	}
+	if errors.Is(err, eventbus.ErrHandlerTimeout) {
+		t.Error("expected the caller's deadline to not be reported as a handler timeout", err)
+	}
+}
+
+func TestPublish_WithConcurrentSubscribers_BoundedByMaxConcurrency(t *testing.T) {
+	bus := eventbus.New(eventbus.WithConcurrentSubscribersBusOpt(), eventbus.WithMaxConcurrencyBusOpt(2))
+	var running, peak, called atomic.Int64
+	for i := 0; i < 6; i++ {
+		bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			running.Add(-1)
+			called.Add(1)
+			return nil
+		})
+	}
+
+	if err := bus.Publish(context.Background(), testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	if called.Load() != 6 {
+		t.Error("expected all subscriptions to be called", called.Load())
+	}
+	if peak.Load() != 2 {
+		t.Error("expected at most 2 subscriptions to run at once", peak.Load())
+	}
+}
+
+func TestPublish_WithConcurrentSubscribersAndFailure_CancelsSiblings(t *testing.T) {
+	bus := eventbus.New(eventbus.WithConcurrentSubscribersBusOpt(), eventbus.WithMaxConcurrencyBusOpt(2))
+	errFailed := errors.New("failed")
+	started := make(chan struct{})
+	siblingErr := make(chan error, 1)
+	bus.On(testEvent).Do(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) error {
+		close(started)
+		<-ctx.Done()
+		siblingErr <- ctx.Err()
+		return ctx.Err()
+	})
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		<-started
+		return errFailed
+	})
+
+	err := bus.Publish(context.Background(), testEvent, nil)
+	if !errors.Is(err, errFailed) {
+		t.Error("expected the first error", err)
+	}
+	if err := <-siblingErr; !errors.Is(err, context.Canceled) {
+		t.Error("expected the sibling to be canceled", err)
+	}
+}
+
+func TestPublish_WithConcurrentSubscribersAndContinueOnError_ReturnsAllErrors(t *testing.T) {
+	bus := eventbus.New(eventbus.WithConcurrentSubscribersBusOpt(), eventbus.WithContinueOnErrorBusOpt())
+	for i := 0; i < 3; i++ {
+		bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+			return errors.New("failed")
+		})
+	}
+
+	err := bus.Publish(context.Background(), testEvent, nil)
+	var errs eventbus.Errors
+	if !errors.As(err, &errs) || len(errs) != 3 {
+		t.Error("expected all errors", err)
+	}
+}
+
+func TestPublish_WithConcurrentSubscribersCanceled_LeavesNoGoroutines(t *testing.T) {
+	bus := eventbus.New(eventbus.WithConcurrentSubscribersBusOpt(), eventbus.WithMaxConcurrencyBusOpt(2))
+	started := make(chan struct{}, 4)
+	for i := 0; i < 4; i++ {
+		bus.On(testEvent).Do(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) error {
+			started <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}
+
+	baseline := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		// Two subscriptions run, and the others wait for a slot.
+		<-started
+		<-started
+		cancel()
+	}()
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, context.Canceled) {
+		t.Error("expected canceled error", err)
+	}
+	bus.Flush(context.Background())
+
+	waitForGoroutines(t, baseline)
+}
+
+func TestClose_WithConcurrentSubscribersRunning_LeavesNoGoroutines(t *testing.T) {
+	bus := eventbus.New(
+		eventbus.WithConcurrentSubscribersBusOpt(),
+		eventbus.WithMaxConcurrencyBusOpt(2),
+		eventbus.WithCancelOnCloseBusOpt(),
+	)
+	started := make(chan struct{}, 4)
+	for i := 0; i < 4; i++ {
+		bus.On(testEvent).Do(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) error {
+			started <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+	}
+
+	baseline := runtime.NumGoroutine()
+	go func() {
+		<-started
+		<-started
+		bus.Close()
+	}()
+	if err := bus.Publish(context.Background(), testEvent, nil); !errors.Is(err, context.Canceled) {
+		t.Error("expected canceled error", err)
+	}
+	bus.Flush(context.Background())
+
+	waitForGoroutines(t, baseline)
+}
+
+func TestPublish_WithDeterministicOption_RunsInRegistrationOrder(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithDeterministicBusOpt())
+	var order []string
+	for _, name := range []string{"o1", "o2", "o3"} {
+		name := name
+		bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+			order = append(order, name)
+		}))
+	}
+	for _, name := range []string{"s1", "s2", "s3"} {
+		name := name
+		bus.When(eventbus.AllMatcher{}).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+			order = append(order, name)
+			return nil
+		})
+	}
+
+	for i := 0; i < 20; i++ {
+		order = nil
+		if err := bus.Publish(ctx, testEvent, nil, eventbus.WithAsyncEventOpt()); err != nil {
+			t.Fatal("expected no error", err)
+		}
+		if got := strings.Join(order, ","); got != "o1,o2,o3,s1,s2,s3" {
+			t.Fatal("expected observers then subscriptions in registration order", got)
+		}
+	}
+}
+
+func TestPublish_WithDeterministicOption_DoesNotSpawnGoroutines(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithDeterministicBusOpt())
+	baseline := runtime.NumGoroutine()
+	var goroutines int
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {}))
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		goroutines = runtime.NumGoroutine()
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithHandlerTimeoutEventOpt(time.Second)); err != nil {
+		t.Error("expected no error", err)
+	}
+	if goroutines != baseline {
+		t.Error("expected the handler to run on the publishing goroutine", goroutines, baseline)
+	}
+}
+
+func TestPublish_WithDeterministicOptionBeforeConcurrencyOptions_StaysDeterministic(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(
+		eventbus.WithDeterministicBusOpt(),
+		eventbus.WithMaxConcurrencyBusOpt(10),
+		eventbus.WithConcurrentSubscribersBusOpt(),
+	)
+	baseline := runtime.NumGoroutine()
+	var order []string
+	goroutines := make(map[int]bool)
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+		goroutines[runtime.NumGoroutine()] = true
+		order = append(order, "o1")
+	}))
+	for _, name := range []string{"s1", "s2", "s3"} {
+		name := name
+		bus.When(eventbus.AllMatcher{}).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+			goroutines[runtime.NumGoroutine()] = true
+			order = append(order, name)
+			return nil
+		})
+	}
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Fatal("expected no error", err)
+	}
+
+	if got := strings.Join(order, ","); got != "o1,s1,s2,s3" {
+		t.Error("expected observers then subscriptions in registration order", got)
+	}
+	if len(goroutines) != 1 || !goroutines[baseline] {
+		t.Error("expected everything to run on the publishing goroutine", goroutines, baseline)
+	}
+}
+
+func TestPublish_WithDeterministicOptionAndFailingObserver_ReturnsError(t *testing.T) {
+	errFailed := errors.New("failed")
+	bus := eventbus.New(eventbus.WithDeterministicBusOpt())
+	bus.AddErrorObserver(errorObserverFunc(func(context.Context, eventbus.Stringer, interface{}) error {
+		return errFailed
+	}))
+
+	if err := bus.Publish(context.Background(), testEvent, nil); !errors.Is(err, errFailed) {
+		t.Error("expected observer error", err)
+	}
+}
+
+func TestPublish_ManyExactSubscriptions_MatcherSubscriptionsStillFire(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var exact, matcher, widened atomic.Int64
+	for i := 0; i < 1000; i++ {
+		bus.On(EventName("event." + strconv.Itoa(i))).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+			exact.Add(1)
+			return nil
+		})
+	}
+	bus.When(eventbus.PrefixMatcher("event.")).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		matcher.Add(1)
+		return nil
+	})
+	bus.On(EventName("other")).Or(eventbus.StringMatcher("event.7")).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		widened.Add(1)
+		return nil
+	})
+
+	for _, name := range []string{"event.7", "event.999", "event.1000"} {
+		if err := bus.Publish(ctx, EventName(name), nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if exact.Load() != 2 {
+		t.Error("expected only the exact subscriptions of the names to fire", exact.Load())
+	}
+	if matcher.Load() != 3 {
+		t.Error("expected the matcher subscription to fire for every event", matcher.Load())
+	}
+	if widened.Load() != 1 {
+		t.Error("expected the widened subscription to fire for its other name", widened.Load())
+	}
+}
+
+func TestSubscription_OrAfterUnsubscribe_DoesNotFire(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	called := false
+	s := bus.On(EventName("other"))
+	s.Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		called = true
+		return nil
+	})
+	s.Unsubscribe()
+	s.Or(eventbus.AllMatcher{})
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	if called {
+		t.Error("expected the unsubscribed subscription to not fire")
+	}
+}
+
+func TestClone_WidenedSubscription_FiresOnClone(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var called atomic.Int64
+	bus.On(EventName("other")).Or(eventbus.AllMatcher{}).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		called.Add(1)
+		return nil
+	})
+
+	if err := bus.Clone().Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	if called.Load() != 1 {
+		t.Error("expected the widened subscription to fire on the clone", called.Load())
+	}
+}
+
+func BenchmarkPublish_10000ExactSubscriptions(b *testing.B) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	for i := 0; i < 10000; i++ {
+		bus.On(EventName("event." + strconv.Itoa(i))).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+			return nil
+		})
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = bus.Publish(ctx, EventName("event.5000"), nil)
+	}
+}
+
+// Matcher subscriptions are matched against every event, like all
+// subscriptions were before exact subscriptions were looked up by name.
+func BenchmarkPublish_10000MatcherSubscriptions(b *testing.B) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	for i := 0; i < 10000; i++ {
+		bus.When(eventbus.StringMatcher("event." + strconv.Itoa(i))).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+			return nil
+		})
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = bus.Publish(ctx, EventName("event.5000"), nil)
+	}
+}
+
+func TestPublish_CanceledWhileAcquiringObserverSlot_ReturnsErrorAndLeavesNoGoroutines(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	bus := eventbus.New(eventbus.WithMaxConcurrencyBusOpt(1))
+	release := make(chan struct{})
+	started := make(chan struct{}, 3)
+	var observed atomic.Int64
+	for i := 0; i < 3; i++ {
+		bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+			observed.Add(1)
+			started <- struct{}{}
+			<-release
+		}))
+	}
+
+	baseline := runtime.NumGoroutine()
+	go func() {
+		// The first observer holds the only slot, so the publish is waiting
+		// for the next one.
+		<-started
+		cancel()
+	}()
+	// Inline, the publish returns the dispatch error instead of returning as
+	// soon as ctx is canceled.
+	err := bus.Publish(ctx, testEvent, nil, eventbus.WithInlineHandlersEventOpt())
+	if !errors.Is(err, context.Canceled) {
+		t.Error("expected canceled error", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "started: 1") {
+		t.Error("expected the error to say how many observers were started", err)
+	}
+
+	close(release)
+	bus.Flush(context.Background())
+	if observed.Load() != 1 {
+		t.Error("expected only the started observer to run", observed.Load())
+	}
+	waitForGoroutines(t, baseline)
+}
+
+func TestPublish_WithStartupEvent_FiresOnceBeforeFirstEvent(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithStartupEventBusOpt())
+	var order []string
+	bus.On(eventbus.EventBusStarted).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		order = append(order, "started")
+		return nil
+	})
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		order = append(order, "test")
+		return nil
+	})
+
+	for i := 0; i < 3; i++ {
+		if err := bus.Publish(ctx, testEvent, nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if got := strings.Join(order, ","); got != "started,test,test,test" {
+		t.Error("expected the startup event to fire once, first", got)
+	}
+}
+
+func TestPublish_WithStartupEventAndConcurrentPublishes_FiresOnce(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithStartupEventBusOpt())
+	var started atomic.Int64
+	bus.On(eventbus.EventBusStarted).Do(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) error {
+		started.Add(1)
+		// Startup handlers can publish without deadlocking.
+		return bus.Publish(ctx, EventName("init"), nil)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = bus.Publish(ctx, testEvent, nil)
+		}()
+	}
+	wg.Wait()
+
+	if started.Load() != 1 {
+		t.Error("expected the startup event to fire once", started.Load())
+	}
+}
+
+func TestPublish_WithoutStartupEvent_DoesNotFire(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	called := false
+	bus.On(eventbus.EventBusStarted).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		called = true
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	if called {
+		t.Error("expected no startup event")
+	}
+}
+
+func TestSubscriptions_KnownRegistrations_Counted(t *testing.T) {
+	bus := eventbus.New()
+	bus.On(testEvent)
+	bus.On(testEvent)
+	bus.On(EventName("other"))
+	bus.When(eventbus.PrefixMatcher("user."), eventbus.SuffixMatcher(".created"))
+	s := bus.On(EventName("gone"))
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {}))
+	id := bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {}))
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {}))
+	s.Unsubscribe()
+	bus.RemoveObserver(id)
+
+	if n := bus.SubscriptionCount(); n != 4 {
+		t.Error("expected 4 subscriptions", n)
+	}
+	if n := bus.ObserverCount(); n != 2 {
+		t.Error("expected 2 observers", n)
+	}
+	subs := bus.Subscriptions()
+	if len(subs) != 3 || subs["test"] != 2 || subs["other"] != 1 || subs["user.* || *.created"] != 1 {
+		t.Error("expected subscriptions to be counted by what they match", subs)
+	}
+}
+
+func TestPublish_WithoutObserversOption_SkipsObserversButRunsHandlers(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var observed, handled atomic.Int64
+	bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+		observed.Add(1)
+	}))
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		handled.Add(1)
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithoutObserversEventOpt()); err != nil {
+		t.Error("expected no error", err)
+	}
+	bus.Flush(ctx)
+
+	if observed.Load() != 0 {
+		t.Error("expected no observer to be notified", observed.Load())
+	}
+	if handled.Load() != 1 {
+		t.Error("expected the handler to run", handled.Load())
+	}
+}
+
+func TestPublish_WithOnlyObserversOption_NotifiesListedObservers(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var mu sync.Mutex
+	observed := make(map[string]int)
+	ids := make([]string, 3)
+	for i := range ids {
+		name := strconv.Itoa(i)
+		ids[i] = bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+			mu.Lock()
+			observed[name]++
+			mu.Unlock()
+		}))
+	}
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithOnlyObserversEventOpt(ids[0], ids[2])); err != nil {
+		t.Error("expected no error", err)
+	}
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithOnlyObserversEventOpt()); err != nil {
+		t.Error("expected no error", err)
+	}
+	bus.Flush(ctx)
+
+	if len(observed) != 2 || observed["0"] != 1 || observed["2"] != 1 {
+		t.Error("expected only the listed observers to be notified", observed)
+	}
+}
+
+func TestDeadSubscriptions_SomeNeverMatch_ReportsThemAfterWindow(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	bus := eventbus.New(eventbus.WithClockBusOpt(clock))
+	live := bus.On(testEvent)
+	stale := bus.On(EventName("stale"))
+	prefix := bus.When(eventbus.PrefixMatcher("te"))
+	never := bus.When(eventbus.PrefixMatcher("never"))
+
+	if dead := bus.DeadSubscriptions(time.Hour); len(dead) != 0 {
+		t.Error("expected no dead subscriptions before the window passed", dead)
+	}
+
+	clock.Advance(30 * time.Minute)
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	clock.Advance(45 * time.Minute)
+
+	dead := bus.DeadSubscriptions(time.Hour)
+	if strings.Join(dead, ",") != stale.String()+","+never.String() {
+		t.Error("expected the subscriptions that never matched", dead)
+	}
+
+	clock.Advance(time.Hour)
+	if dead := bus.DeadSubscriptions(time.Hour); len(dead) != 4 || dead[0] != live.String() || dead[2] != prefix.String() {
+		t.Error("expected every subscription once none matched within the window", dead)
+	}
+}
+
+func TestClose_CalledConcurrently_DoesNotPanic(t *testing.T) {
+	bus := eventbus.New()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bus.Close()
+		}()
+	}
+	wg.Wait()
+
+	if err := bus.Publish(context.Background(), testEvent, nil); !errors.Is(err, eventbus.ErrBusClosed) {
+		t.Error("expected ErrBusClosed", err)
+	}
+}
+
+func TestShutdown_FastHandler_ReturnsNilOnceFinished(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var handled atomic.Bool
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		time.Sleep(5 * time.Millisecond)
+		handled.Store(true)
+		return nil
+	})
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithAsyncEventOpt()); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := bus.Shutdown(ctx); err != nil {
+		t.Error("expected no error", err)
+	}
+	if !handled.Load() {
+		t.Error("expected the publish to finish before Shutdown returns")
+	}
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, eventbus.ErrBusClosed) {
+		t.Error("expected ErrBusClosed", err)
+	}
+}
+
+func TestShutdown_SlowHandler_ReturnsDeadlineExceeded(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	release := make(chan struct{})
+	defer close(release)
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		<-release
+		return nil
+	})
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithAsyncEventOpt()); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := bus.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected context.DeadlineExceeded", err)
+	}
+}
+
+func TestPublish_WithDefaultHandlerTimeout_FailsAfterTimeout(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithDefaultHandlerTimeoutBusOpt(10 * time.Millisecond))
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, eventbus.ErrHandlerTimeout) {
+		t.Error("expected ErrHandlerTimeout error", err)
+	}
+}
+
+func TestPublish_WithDefaultHandlerTimeoutAndLongerEventTimeout_KeepsDefault(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithDefaultHandlerTimeoutBusOpt(10 * time.Millisecond))
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	})
+
+	err := bus.Publish(ctx, testEvent, nil, eventbus.WithHandlerTimeoutEventOpt(time.Second))
+	if !errors.Is(err, eventbus.ErrHandlerTimeout) {
+		t.Error("expected the event timeout to not loosen the default", err)
+	}
+}
+
+func TestPublish_WithDefaultHandlerTimeoutAndShorterEventTimeout_TightensDefault(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithDefaultHandlerTimeoutBusOpt(time.Second))
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	})
+
+	start := time.Now()
+	err := bus.Publish(ctx, testEvent, nil, eventbus.WithHandlerTimeoutEventOpt(10*time.Millisecond))
+	if !errors.Is(err, eventbus.ErrHandlerTimeout) {
+		t.Error("expected the event timeout to tighten the default", err)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Error("expected the publish to time out before the default", elapsed)
+	}
+}
+
+func TestPublish_HandlerPanics_ReturnsPanicErrorAndRunsOtherSubscribers(t *testing.T) {
+	ctx := context.Background()
+	var recovered []interface{}
+	var mu sync.Mutex
+	bus := eventbus.New(
+		eventbus.WithContinueOnErrorBusOpt(),
+		eventbus.WithPanicHandlerBusOpt(func(r interface{}, stack []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			recovered = append(recovered, r)
+			if len(stack) == 0 {
+				t.Error("expected the panic stack")
+			}
+		}),
+	)
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		panic("handler failed")
+	})
+	called := false
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		called = true
+		return nil
+	})
+
+	var perr *eventbus.PanicError
+	if err := bus.Publish(ctx, testEvent, nil); !errors.As(err, &perr) || perr.Value != "handler failed" {
+		t.Error("expected panic error", err)
+	}
+	if !called {
+		t.Error("expected the other subscriber to run")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(recovered) != 1 || recovered[0] != "handler failed" {
+		t.Error("expected the panic handler to be called", recovered)
+	}
+}
+
+func TestPublish_HandlerOrObserverPanics_ReportsEachPanicOnce(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	var errorsHandled, panicsHandled []interface{}
+	bus := eventbus.New(
+		eventbus.WithErrorHandlerBusOpt(func(_ context.Context, err error) {
+			var perr *eventbus.PanicError
+			if errors.As(err, &perr) {
+				mu.Lock()
+				defer mu.Unlock()
+				errorsHandled = append(errorsHandled, perr.Value)
+			}
+		}),
+		eventbus.WithPanicHandlerBusOpt(func(r interface{}, _ []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			panicsHandled = append(panicsHandled, r)
+		}),
+	)
+	bus.On(EventName("handler")).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		panic("handler failed")
+	})
+	bus.AddObserver(observerFunc(func(_ context.Context, name eventbus.Stringer, _ interface{}) {
+		if name.String() == "observer" {
+			panic("observer failed")
+		}
+	}))
+
+	for _, name := range []EventName{"handler", "observer"} {
+		var perr *eventbus.PanicError
+		if err := bus.Publish(ctx, name, nil); !errors.As(err, &perr) {
+			t.Error("expected panic error", name, err)
+		}
+	}
+	bus.Flush(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errorsHandled) != 2 || errorsHandled[0] != "handler failed" || errorsHandled[1] != "observer failed" {
+		t.Error("expected the error handler to get each panic once", errorsHandled)
+	}
+	if len(panicsHandled) != 2 || panicsHandled[0] != "handler failed" || panicsHandled[1] != "observer failed" {
+		t.Error("expected the panic handler to get each panic once", panicsHandled)
+	}
+}
+
+func TestPublish_InlineHandlerPanics_ReturnsPanicError(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		panic("handler failed")
+	})
+
+	var perr *eventbus.PanicError
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithInlineHandlersEventOpt()); !errors.As(err, &perr) {
+		t.Error("expected panic error", err)
+	}
+}
+
+func TestClose_WithCancelOnClose_CancelsRunningHandler(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithCancelOnCloseBusOpt())
+	started := make(chan struct{})
+	cause := make(chan error, 1)
+	bus.On(testEvent).Do(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) error {
+		close(started)
+		<-ctx.Done()
+		cause <- context.Cause(ctx)
+		return ctx.Err()
+	})
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithAsyncEventOpt()); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	<-started
+	bus.Close()
+
+	select {
+	case err := <-cause:
+		if !errors.Is(err, eventbus.ErrBusClosed) {
+			t.Error("expected ErrBusClosed as the cause", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the handler to return once the bus closed")
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if err := bus.Shutdown(waitCtx); err != nil {
+		t.Error("expected no error", err)
+	}
+}
+
+func TestClose_WithCancelOnClose_CancelsRunningObserver(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithCancelOnCloseBusOpt())
+	started := make(chan struct{})
+	cause := make(chan error, 1)
+	bus.AddObserver(observerFunc(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) {
+		close(started)
+		select {
+		case <-ctx.Done():
+			cause <- context.Cause(ctx)
+		case <-time.After(time.Second):
+			cause <- nil
+		}
+	}))
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithAsyncEventOpt()); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	<-started
+	bus.Close()
+
+	if err := <-cause; !errors.Is(err, eventbus.ErrBusClosed) {
+		t.Error("expected the observer to be canceled with ErrBusClosed", err)
+	}
+	bus.Flush(ctx)
+}
+
+func TestClose_WithCancelOnCloseAndContextKeys_CancelsObserverWithErrBusClosed(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithCancelOnCloseBusOpt(), eventbus.WithContextKeysBusOpt())
+	started := make(chan struct{})
+	cause := make(chan error, 1)
+	bus.AddObserver(observerFunc(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) {
+		close(started)
+		select {
+		case <-ctx.Done():
+			cause <- context.Cause(ctx)
+		case <-time.After(time.Second):
+			cause <- nil
+		}
+	}))
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithAsyncEventOpt()); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	<-started
+	bus.Close()
+
+	if err := <-cause; !errors.Is(err, eventbus.ErrBusClosed) {
+		t.Error("expected the observer to be canceled with ErrBusClosed", err)
+	}
+	bus.Flush(ctx)
+}
@@ -0,0 +1,35 @@
+package eventbus
+
+import "time"
+
+type (
+	// Clock tells the time and creates tickers for the bus. The default uses
+	// the time package; tests can inject their own with WithClockBusOpt.
+	Clock interface {
+		Now() time.Time
+		NewTicker(d time.Duration) Ticker
+	}
+
+	// Ticker delivers ticks at intervals, like a time.Ticker.
+	Ticker interface {
+		C() <-chan time.Time
+		Stop()
+	}
+
+	realClock  struct{}
+	realTicker struct {
+		*time.Ticker
+	}
+)
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
@@ -0,0 +1,83 @@
+package eventbus_test
+
+import (
+	"sync"
+	"time"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+)
+
+type (
+	// fakeClock is a manually advanced eventbus.Clock.
+	fakeClock struct {
+		mu      sync.Mutex
+		now     time.Time
+		tickers []*fakeTicker
+	}
+
+	fakeTicker struct {
+		clock   *fakeClock
+		c       chan time.Time
+		d       time.Duration
+		next    time.Time
+		stopped bool
+	}
+)
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) eventbus.Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTicker{clock: c, c: make(chan time.Time, 1), d: d, next: c.now.Add(d)}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+// Advance moves the clock forward, firing any tickers that come due. Like a
+// time.Ticker, ticks are dropped when the previous one hasn't been received.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, t := range c.tickers {
+		for !t.stopped && !t.next.After(c.now) {
+			select {
+			case t.c <- t.next:
+			default:
+			}
+			t.next = t.next.Add(t.d)
+		}
+	}
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.stopped = true
+}
+
+// waitForTickers waits until n tickers have been created on the clock.
+func (c *fakeClock) waitForTickers(n int) {
+	for {
+		c.mu.Lock()
+		created := len(c.tickers)
+		c.mu.Unlock()
+		if created >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
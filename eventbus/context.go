@@ -0,0 +1,129 @@
+package eventbus
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// publishStartKey is the context key of the time a publish started.
+type publishStartKey struct{}
+
+// PublishStartFromContext returns the time the publish that ctx belongs to
+// started, so handlers can tell how long it has been running. It reports false
+// if ctx doesn't belong to a publish.
+func PublishStartFromContext(ctx context.Context) (time.Time, bool) {
+	start, ok := ctx.Value(publishStartKey{}).(time.Time)
+	return start, ok
+}
+
+// headersKey is the context key of the headers of the event being handled.
+type headersKey struct{}
+
+// HeadersFromContext returns the headers of the event that ctx was passed to a
+// handler or observer for, or nil if it has none. The map must not be
+// modified.
+func HeadersFromContext(ctx context.Context) map[string]string {
+	headers, _ := ctx.Value(headersKey{}).(map[string]string)
+	return headers
+}
+
+// eventKey is the context key of the event being handled.
+type eventKey struct{}
+
+// eventFromContext returns the event that ctx was passed to a handler or
+// observer for.
+func eventFromContext(ctx context.Context) (Event, bool) {
+	e, ok := ctx.Value(eventKey{}).(Event)
+	return e, ok
+}
+
+// detachedContext carries values of its parent, but not its deadline or
+// cancellation. It is used for work that outlives the publish that started it.
+// If keys is not nil, only the values of the listed keys are carried.
+type detachedContext struct {
+	parent context.Context
+	keys   []interface{}
+}
+
+// detach returns a context for work that outlives the publish ctx belongs to,
+// carrying the values of the keys configured with WithContextKeysBusOpt, or
+// all values if none were.
+func (b *bus) detach(ctx context.Context) context.Context {
+	d := detachedContext{parent: ctx, keys: b.contextKeys}
+	if b.tracer != nil {
+		// Spans of detached work stay children of the publish span.
+		return trace.ContextWithSpan(d, trace.SpanFromContext(ctx))
+	}
+	return d
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return carriedValue(c.parent, c.keys, key)
+}
+
+// scope returns a context for observers of the publish ctx belongs to. It is
+// canceled with the publish, with the same cause, but carries only the values
+// of the keys configured with WithContextKeysBusOpt, or all values if none
+// were. The returned function releases it.
+func (b *bus) scope(ctx context.Context) (context.Context, func()) {
+	if b.contextKeys == nil {
+		return ctx, func() {}
+	}
+
+	// The values are scoped first, and the deadline and cancellation added
+	// back on top, so that context.Cause still finds the cause.
+	s := b.detach(ctx)
+	stopDeadline := func() {}
+	if deadline, ok := ctx.Deadline(); ok {
+		s, stopDeadline = context.WithDeadline(s, deadline)
+	}
+	s, cancel := context.WithCancelCause(s)
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancel(context.Cause(ctx))
+		case <-stop:
+		}
+	}()
+
+	return s, func() {
+		close(stop)
+		cancel(nil)
+		stopDeadline()
+	}
+}
+
+// carriedValue returns the value of key in parent if key is one of keys, or
+// is stored by the bus itself. A nil keys carries every value.
+func carriedValue(parent context.Context, keys []interface{}, key interface{}) interface{} {
+	if keys == nil {
+		return parent.Value(key)
+	}
+
+	switch key.(type) {
+	case publishStartKey, headersKey, eventKey:
+		return parent.Value(key)
+	}
+
+	for _, k := range keys {
+		if k == key {
+			return parent.Value(key)
+		}
+	}
+	return nil
+}
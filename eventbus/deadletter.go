@@ -0,0 +1,32 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultDeadLetterTimeout bounds how long a publish waits for the dead-letter
+// function unless WithDeadLetterTimeoutBusOpt says otherwise.
+const defaultDeadLetterTimeout = 5 * time.Second
+
+// deadLetter passes an event that a subscription failed to handle to the
+// dead-letter function, if one is configured. The function gets a context
+// that isn't canceled with the publish, bounded by the dead-letter timeout; if
+// it takes longer, the publish moves on and the timeout is reported to the
+// error handler.
+func (b *bus) deadLetter(ctx context.Context, e Event, err error) {
+	if b.deadLetterFn == nil {
+		return
+	}
+
+	ctx = b.detach(ctx)
+	werr := doWithTimeout(ctx, b.deadLetterTimeout, ErrHandlerTimeout, func(ctx context.Context) error {
+		b.deadLetterFn(ctx, e, err)
+		return nil
+	})
+	if errors.Is(werr, ErrHandlerTimeout) {
+		b.handleError(ctx, fmt.Errorf("dead letter error; event: %v: %w", e, werr))
+	}
+}
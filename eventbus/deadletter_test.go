@@ -0,0 +1,116 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+)
+
+type deadLetters struct {
+	mu     sync.Mutex
+	events []eventbus.Event
+	errs   []error
+}
+
+func (d *deadLetters) record(_ context.Context, e eventbus.Event, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.events = append(d.events, e)
+	d.errs = append(d.errs, err)
+}
+
+func TestPublish_WithDeadLetterAndFailingHandler_ReceivesEventAndError(t *testing.T) {
+	ctx := context.Background()
+	var dl deadLetters
+	bus := eventbus.New(eventbus.WithDeadLetterBusOpt(dl.record))
+	errFailed := errors.New("failed")
+	calls := 0
+	bus.On(testEvent).WithRetry(2, nil).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		calls++
+		return errFailed
+	})
+
+	if err := bus.Publish(ctx, testEvent, "payload"); !errors.Is(err, errFailed) {
+		t.Error("expected handler error", err)
+	}
+
+	if len(dl.events) != 1 {
+		t.Fatal("expected one dead letter after the retries", dl.events)
+	}
+	if dl.events[0].Name != testEvent || dl.events[0].Data != "payload" || dl.events[0].ID == "" {
+		t.Error("expected the full event", dl.events[0])
+	}
+	if !errors.Is(dl.errs[0], errFailed) {
+		t.Error("expected the handler error", dl.errs[0])
+	}
+	if calls != 2 {
+		t.Error("expected the handler to be retried first", calls)
+	}
+}
+
+func TestPublish_WithDeadLetterAndSucceedingHandlers_NotCalled(t *testing.T) {
+	ctx := context.Background()
+	var dl deadLetters
+	bus := eventbus.New(eventbus.WithDeadLetterBusOpt(dl.record))
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	if len(dl.events) != 0 {
+		t.Error("expected no dead letters", dl.events)
+	}
+}
+
+func TestPublish_WithDeadLetterAndContinueOnError_CalledPerFailingSubscription(t *testing.T) {
+	ctx := context.Background()
+	var dl deadLetters
+	bus := eventbus.New(eventbus.WithDeadLetterBusOpt(dl.record), eventbus.WithContinueOnErrorBusOpt())
+	for i := 0; i < 2; i++ {
+		bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+			return errTransient
+		})
+	}
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, errTransient) {
+		t.Error("expected handler error", err)
+	}
+	if len(dl.errs) != 2 || !errors.Is(dl.errs[0], errTransient) || !errors.Is(dl.errs[1], errTransient) {
+		t.Error("expected a dead letter per failing subscription", dl.errs)
+	}
+}
+
+func TestPublish_WithSlowDeadLetter_BoundedByTimeout(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	defer close(release)
+	var reported error
+	bus := eventbus.New(
+		eventbus.WithDeadLetterBusOpt(func(context.Context, eventbus.Event, error) { <-release }),
+		eventbus.WithDeadLetterTimeoutBusOpt(10*time.Millisecond),
+		eventbus.WithErrorHandlerBusOpt(func(_ context.Context, err error) { reported = err }),
+	)
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		return errTransient
+	})
+
+	start := time.Now()
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, errTransient) {
+		t.Error("expected handler error", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Error("expected the publish to stop waiting for the dead letter", elapsed)
+	}
+	if !errors.Is(reported, eventbus.ErrHandlerTimeout) {
+		t.Error("expected the timeout to be reported", reported)
+	}
+}
@@ -0,0 +1,92 @@
+package eventbus
+
+import (
+	"sync"
+	"time"
+)
+
+// dedupeMaxKeys caps the keys a deduper remembers. Beyond it, the oldest keys
+// are forgotten before their window ends.
+const dedupeMaxKeys = 10000
+
+type (
+	// deduper remembers the keys of the events published within a window.
+	deduper struct {
+		mu     sync.Mutex
+		window time.Duration
+		keyFn  func(Event) string
+		seen   map[string]time.Time
+		// order lists the remembered keys oldest first, to forget them once
+		// they expire.
+		order []dedupeEntry
+	}
+
+	dedupeEntry struct {
+		key string
+		at  time.Time
+	}
+)
+
+func newDeduper(window time.Duration, keyFn func(Event) string) *deduper {
+	return &deduper{window: window, keyFn: keyFn, seen: make(map[string]time.Time)}
+}
+
+// duplicate reports whether an event with the same key as e was published
+// within the window before now, and otherwise remembers e's key. Events with
+// an empty key are never duplicates. If the publish of e is then rejected, its
+// key must be forgotten with release.
+func (d *deduper) duplicate(e Event, now time.Time) bool {
+	key := d.keyFn(e)
+	if key == "" {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.expire(now)
+	if at, ok := d.seen[key]; ok && now.Sub(at) < d.window {
+		return true
+	}
+
+	d.seen[key] = now
+	d.order = append(d.order, dedupeEntry{key: key, at: now})
+	return false
+}
+
+// release forgets e's key as remembered by duplicate at now, so that a retry of
+// a rejected publish isn't taken for a duplicate.
+func (d *deduper) release(e Event, now time.Time) {
+	key := d.keyFn(e)
+	if key == "" {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// The key may have been remembered again since; its entry in order is
+	// skipped once it expires.
+	if d.seen[key].Equal(now) {
+		delete(d.seen, key)
+	}
+}
+
+// expire forgets the keys whose window has ended by now, and the oldest keys
+// beyond dedupeMaxKeys.
+func (d *deduper) expire(now time.Time) {
+	n := 0
+	for ; n < len(d.order); n++ {
+		entry := d.order[n]
+		if now.Sub(entry.at) < d.window && len(d.order)-n < dedupeMaxKeys {
+			break
+		}
+		// The key may have been remembered again since.
+		if d.seen[entry.key].Equal(entry.at) {
+			delete(d.seen, entry.key)
+		}
+	}
+	// Appending reallocates the slice once it runs out of room, dropping the
+	// forgotten entries.
+	d.order = d.order[n:]
+}
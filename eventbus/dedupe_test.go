@@ -0,0 +1,119 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+	"golang.org/x/time/rate"
+)
+
+func idempotencyKey(e eventbus.Event) string {
+	if key := e.Headers["idempotency-key"]; key != "" {
+		return e.Name.String() + ":" + key
+	}
+	return ""
+}
+
+func TestPublish_WithDedupe_DropsDuplicateWithinWindow(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	bus := eventbus.New(eventbus.WithClockBusOpt(clock), eventbus.WithDedupeBusOpt(time.Minute, idempotencyKey))
+	handled := 0
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		handled++
+		return nil
+	})
+	key := eventbus.WithHeaderEventOpt("idempotency-key", "1")
+
+	if err := bus.Publish(ctx, testEvent, nil, key); err != nil {
+		t.Error("expected no error", err)
+	}
+	clock.Advance(30 * time.Second)
+	if err := bus.Publish(ctx, testEvent, nil, key); !errors.Is(err, eventbus.ErrDuplicate) {
+		t.Error("expected ErrDuplicate", err)
+	}
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected events without a key to not be deduplicated", err)
+	}
+
+	if handled != 2 {
+		t.Error("expected the duplicate to be dropped", handled)
+	}
+}
+
+func TestPublish_WithDedupe_DeliversDuplicateAfterWindow(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	bus := eventbus.New(eventbus.WithClockBusOpt(clock), eventbus.WithDedupeBusOpt(time.Minute, idempotencyKey))
+	handled := 0
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		handled++
+		return nil
+	})
+	key := eventbus.WithHeaderEventOpt("idempotency-key", "1")
+
+	if err := bus.Publish(ctx, testEvent, nil, key); err != nil {
+		t.Error("expected no error", err)
+	}
+	clock.Advance(time.Minute)
+	if err := bus.Publish(ctx, testEvent, nil, key); err != nil {
+		t.Error("expected no error after the window", err)
+	}
+
+	if handled != 2 {
+		t.Error("expected both events to be delivered", handled)
+	}
+}
+
+func TestPublish_WithSilentDedupe_DropsDuplicateWithoutError(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithDedupeBusOpt(time.Minute, idempotencyKey), eventbus.WithSilentDedupeBusOpt())
+	handled := 0
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		handled++
+		return nil
+	})
+	key := eventbus.WithHeaderEventOpt("idempotency-key", "1")
+
+	for i := 0; i < 2; i++ {
+		if err := bus.Publish(ctx, testEvent, nil, key); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if handled != 1 {
+		t.Error("expected the duplicate to be dropped", handled)
+	}
+}
+
+func TestPublish_WithDedupeRetryAfterRateLimited_IsNotDuplicate(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(
+		eventbus.WithDedupeBusOpt(time.Minute, idempotencyKey),
+		eventbus.WithRateLimitBusOpt(testEvent, rate.Every(20*time.Millisecond), 1),
+	)
+	handled := 0
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		handled++
+		return nil
+	})
+	key := eventbus.WithHeaderEventOpt("idempotency-key", "1")
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	if err := bus.Publish(ctx, testEvent, nil, key); !errors.Is(err, eventbus.ErrRateLimited) {
+		t.Error("expected ErrRateLimited", err)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if err := bus.Publish(ctx, testEvent, nil, key); err != nil {
+		t.Error("expected the retry to be delivered", err)
+	}
+
+	if handled != 2 {
+		t.Error("expected the retry to be handled", handled)
+	}
+}
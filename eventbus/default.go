@@ -1,7 +1,10 @@
 // eventtbus is a package for a simple event bus.
 package eventbus
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 var _default = New()
 
@@ -15,22 +18,115 @@ func On(name Stringer) *subscription {
 	return _default.On(name)
 }
 
+// OnGroup subscribes to an event by name as a member of the group in the
+// default event bus.
+func OnGroup(group string, name Stringer) *subscription {
+	return _default.OnGroup(group, name)
+}
+
 // When subscribes to an event by arbitrary matchers in the default event bus.
 func When(matchers ...Matcher) *subscription {
 	return _default.When(matchers...)
 }
 
+// OnAny subscribes a handler to every event in the default event bus.
+func OnAny(fn Handler) *subscription {
+	return _default.OnAny(fn)
+}
+
+// Moves a subscription to the provided index among the subscriptions to the
+// same event name in the default event bus.
+func MoveSubscription(id string, toIndex int) bool {
+	return _default.MoveSubscription(id, toIndex)
+}
+
+// Removes a subscription from the default event bus.
+func Unsubscribe(s *subscription) bool {
+	return _default.Unsubscribe(s)
+}
+
+// OnKeys subscribes to events published with PublishKeys whose keys include all
+// of the provided pairs in the default event bus.
+func OnKeys(keys map[string]string, fn Handler) *subscription {
+	return _default.OnKeys(keys, fn)
+}
+
 // Publishes an event with the provided name and data.
 func Publish(ctx context.Context, name Stringer, data interface{}, opts ...eventOpt) error {
 	return _default.Publish(ctx, name, data, opts...)
 }
 
+// Publishes an event named by the provided keys.
+func PublishKeys(ctx context.Context, keys map[string]string, data interface{}, opts ...eventOpt) error {
+	return _default.PublishKeys(ctx, keys, data, opts...)
+}
+
+// Publishes an event only if at least minSubscribers subscriptions match it.
+func PublishRequire(ctx context.Context, name Stringer, data interface{}, minSubscribers int, opts ...eventOpt) error {
+	return _default.PublishRequire(ctx, name, data, minSubscribers, opts...)
+}
+
+// Publishes the same data under each of the provided names in the default
+// event bus.
+func PublishMulti(ctx context.Context, names []Stringer, data interface{}, opts ...eventOpt) error {
+	return _default.PublishMulti(ctx, names, data, opts...)
+}
+
+// Publishes an event like Publish, and reports the result of every
+// subscription and observer.
+func PublishResult(ctx context.Context, name Stringer, data interface{}, opts ...eventOpt) (PublishReport, error) {
+	return _default.PublishResult(ctx, name, data, opts...)
+}
+
+// Returns the publishes that are currently executing, oldest first.
+func InFlight() []InFlightPublish {
+	return _default.InFlight()
+}
+
+// Returns the number of registered subscriptions.
+func SubscriptionCount() int {
+	return _default.SubscriptionCount()
+}
+
+// Returns the number of registered observers.
+func ObserverCount() int {
+	return _default.ObserverCount()
+}
+
+// Returns the number of registered subscriptions by what they match.
+func Subscriptions() map[string]int {
+	return _default.Subscriptions()
+}
+
+// Returns the IDs of the subscriptions that haven't matched a published event
+// within the window.
+func DeadSubscriptions(window time.Duration) []string {
+	return _default.DeadSubscriptions(window)
+}
+
+// Returns the fraction, from 0 to 1, of the publishes of the event name in the
+// last minute that returned an error.
+func ErrorRate(name Stringer) float64 {
+	return _default.ErrorRate(name)
+}
+
+// Returns the number of publishes waiting for a worker when the bus was
+// created with WithQueueBusOpt, or 0 otherwise.
+func QueueDepth() int {
+	return _default.QueueDepth()
+}
+
 // Adds an observer. Observers are notified of all published events, and are
 // executed in parallel.
 func AddObserver(o observer, opts ...observerOpt) string {
 	return _default.AddObserver(o, opts...)
 }
 
+// Adds an observer that can fail to the default event bus.
+func AddErrorObserver(o ErrorObserver, opts ...observerOpt) string {
+	return _default.AddErrorObserver(o, opts...)
+}
+
 // Removes an observer.
 func RemoveObserver(id string) bool {
 	return _default.RemoveObserver(id)
@@ -41,6 +137,24 @@ func Flush(ctx context.Context) {
 	_default.Flush(ctx)
 }
 
+// Returns a channel that receives the errors of observers in the default event
+// bus.
+func ObserverErrors() <-chan error {
+	return _default.ObserverErrors()
+}
+
+// Waits until no publish has started or finished for idle, and none is in
+// flight. Returns the error of ctx if it is done first.
+func FlushIdle(ctx context.Context, idle time.Duration) error {
+	return _default.FlushIdle(ctx, idle)
+}
+
+// Flushes the bus every interval until ctx is done, calling cb with the number
+// of publishes that completed since the previous flush.
+func AutoFlush(ctx context.Context, interval time.Duration, cb func(flushed int)) {
+	_default.AutoFlush(ctx, interval, cb)
+}
+
 // Waits for the bus to be closed and then flushes.
 func Wait(ctx context.Context) {
 	_default.Wait(ctx)
@@ -50,3 +164,15 @@ func Wait(ctx context.Context) {
 func Close() {
 	_default.Close()
 }
+
+// Closes the bus and waits for the publishes in flight to finish. Returns the
+// error of ctx if it is done before they finish.
+func Shutdown(ctx context.Context) error {
+	return _default.Shutdown(ctx)
+}
+
+// Waits for the next event that m matches to be published to the default event
+// bus, and returns it. Returns the error of ctx if it is done first.
+func WaitForEvent(ctx context.Context, m Matcher) (Event, error) {
+	return _default.WaitForEvent(ctx, m)
+}
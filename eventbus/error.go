@@ -2,10 +2,28 @@ package eventbus
 
 import (
 	"errors"
+	"fmt"
+	"runtime/debug"
 	"strings"
 )
 
-type Errors []error
+type (
+	Errors []error
+
+	// StackError is an error along with the stack trace of the goroutine that
+	// caused it.
+	StackError struct {
+		Err   error
+		Stack []byte
+	}
+
+	// PanicError is a recovered panic along with the stack trace of the
+	// goroutine that panicked.
+	PanicError struct {
+		Value interface{}
+		Stack []byte
+	}
+)
 
 func (e Errors) Error() string {
 	var strs []string
@@ -15,7 +33,67 @@ func (e Errors) Error() string {
 	return strings.Join(strs, "\n")
 }
 
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+func (e *StackError) Error() string {
+	return e.Err.Error() + "\n\n" + string(e.Stack)
+}
+
+func (e *StackError) Unwrap() error {
+	return e.Err
+}
+
+func newPanicError(value interface{}) *PanicError {
+	return &PanicError{
+		Value: value,
+		Stack: debug.Stack(),
+	}
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n\n%s", e.Value, e.Stack)
+}
+
+// joinErrors combines the non-nil errors, flattening any Errors among them. A
+// lone error is returned as is.
+func joinErrors(errs ...error) error {
+	var joined Errors
+	for _, err := range errs {
+		switch err := err.(type) {
+		case nil:
+		case Errors:
+			joined = append(joined, err...)
+		default:
+			joined = append(joined, err)
+		}
+	}
+
+	switch len(joined) {
+	case 0:
+		return nil
+	case 1:
+		return joined[0]
+	}
+	return joined
+}
+
 var (
-	ErrBusClosed = errors.New("bus is closed")
-	// TODO: add more errors, for example to differentiate between publishing timeout and handler timeout, etc. as well as other internal errors
+	ErrBusClosed               = errors.New("bus is closed")
+	ErrInsufficientSubscribers = errors.New("insufficient subscribers")
+	ErrNilData                 = errors.New("event data is nil")
+	ErrDataType                = errors.New("event data has unexpected type")
+	// ErrHandlerTimeout is returned, along with context.DeadlineExceeded, when
+	// a handler or observer exceeds its timeout.
+	ErrHandlerTimeout = errors.New("handler timed out")
+	// ErrPublishTimeout is returned, along with context.DeadlineExceeded, when
+	// a publish exceeds its timeout.
+	ErrPublishTimeout = errors.New("publish timed out")
+	// ErrRateLimited is returned when a publish exceeds the rate limit of its
+	// event name.
+	ErrRateLimited = errors.New("publish rate limited")
+	// ErrDuplicate is returned when a publish is dropped as a duplicate of an
+	// event published within the dedupe window.
+	ErrDuplicate = errors.New("duplicate event")
 )
@@ -0,0 +1,97 @@
+package eventbus
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// errorRateWindow is how far back ErrorRate looks.
+	errorRateWindow = time.Minute
+	// errorRateBuckets is how many buckets the window is divided into. The
+	// window slides a bucket at a time.
+	errorRateBuckets = 60
+)
+
+type (
+	// errorRates counts the publishes and failed publishes of each event name
+	// in time buckets, to compute error rates over a sliding window.
+	errorRates struct {
+		mu     sync.Mutex
+		bucket time.Duration
+		names  map[string]*[errorRateBuckets]rateBucket
+	}
+
+	rateBucket struct {
+		// index is the bucket's position since the Unix epoch, telling apart
+		// the buckets that share a slot.
+		index  int64
+		total  int
+		failed int
+	}
+)
+
+func newErrorRates() *errorRates {
+	return &errorRates{
+		bucket: errorRateWindow / errorRateBuckets,
+		names:  make(map[string]*[errorRateBuckets]rateBucket),
+	}
+}
+
+// record counts a publish of the event name at now.
+func (r *errorRates) record(name string, now time.Time, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	buckets, ok := r.names[name]
+	if !ok {
+		buckets = &[errorRateBuckets]rateBucket{}
+		r.names[name] = buckets
+	}
+
+	index := now.UnixNano() / int64(r.bucket)
+	b := &buckets[index%errorRateBuckets]
+	if b.index != index {
+		*b = rateBucket{index: index}
+	}
+	b.total++
+	if failed {
+		b.failed++
+	}
+}
+
+// rate returns the fraction of the publishes of the event name within the
+// window ending at now that failed.
+func (r *errorRates) rate(name string, now time.Time) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	buckets, ok := r.names[name]
+	if !ok {
+		return 0
+	}
+
+	index := now.UnixNano() / int64(r.bucket)
+	total, failed := 0, 0
+	for _, b := range buckets {
+		if b.index > index-errorRateBuckets && b.index <= index {
+			total += b.total
+			failed += b.failed
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(failed) / float64(total)
+}
+
+// Returns the fraction, from 0 to 1, of the publishes of the event name in the
+// last minute that returned an error, for example because a handler failed.
+// Returns 0 if there were none, or if the bus wasn't created with
+// WithErrorRateTrackingBusOpt.
+func (b *bus) ErrorRate(name Stringer) float64 {
+	if b.errorRates == nil {
+		return 0
+	}
+	return b.errorRates.rate(name.String(), b.clock.Now())
+}
@@ -0,0 +1,73 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+)
+
+func TestErrorRate_MixedResults_ReturnsFailedFraction(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	bus := eventbus.New(eventbus.WithClockBusOpt(clock), eventbus.WithErrorRateTrackingBusOpt())
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, data interface{}) error {
+		if data == "fail" {
+			return errors.New("handler error")
+		}
+		return nil
+	})
+
+	for _, data := range []string{"ok", "fail", "ok", "fail"} {
+		_ = bus.Publish(ctx, testEvent, data)
+		clock.Advance(time.Second)
+	}
+
+	if rate := bus.ErrorRate(testEvent); rate != 0.5 {
+		t.Error("expected an error rate of 0.5", rate)
+	}
+	if rate := bus.ErrorRate(EventName("other")); rate != 0 {
+		t.Error("expected an error rate of 0 for an unpublished event", rate)
+	}
+}
+
+func TestErrorRate_WindowPassed_ForgetsOldPublishes(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	bus := eventbus.New(eventbus.WithClockBusOpt(clock), eventbus.WithErrorRateTrackingBusOpt())
+	fail := true
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		if fail {
+			return errors.New("handler error")
+		}
+		return nil
+	})
+
+	_ = bus.Publish(ctx, testEvent, nil)
+	clock.Advance(30 * time.Second)
+	fail = false
+	_ = bus.Publish(ctx, testEvent, nil)
+
+	if rate := bus.ErrorRate(testEvent); rate != 0.5 {
+		t.Error("expected an error rate of 0.5 within the window", rate)
+	}
+
+	clock.Advance(45 * time.Second)
+	if rate := bus.ErrorRate(testEvent); rate != 0 {
+		t.Error("expected the failed publish to leave the window", rate)
+	}
+}
+
+func TestErrorRate_WithoutTracking_ReturnsZero(t *testing.T) {
+	bus := eventbus.New()
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		return errors.New("handler error")
+	})
+	_ = bus.Publish(context.Background(), testEvent, nil)
+
+	if rate := bus.ErrorRate(testEvent); rate != 0 {
+		t.Error("expected an error rate of 0 without tracking", rate)
+	}
+}
@@ -1,6 +1,7 @@
 package eventbus
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/almahoozi/go-eventbus/pkg/id"
@@ -11,20 +12,94 @@ type (
 		String() string
 	}
 	Event struct {
-		ID             string      `json:"id"`
-		Name           Stringer    `json:"name"`
-		Data           interface{} `json:"data"`
-		Timestamp      time.Time   `json:"timestamp"`
+		ID        string      `json:"id"`
+		Name      Stringer    `json:"name"`
+		Data      interface{} `json:"data"`
+		Timestamp time.Time   `json:"timestamp"`
+		// Headers carry metadata, such as correlation IDs, alongside the data.
+		// Handlers read them with HeadersFromContext.
+		Headers        map[string]string `json:"headers,omitempty"`
 		handlerTimeout time.Duration
 		publishTimeout time.Duration
+		inline         bool
+		async          bool
+		unhandled      bool
+		trace          *MatchTrace
+		report         *publishReport
+		noObservers    bool
+		onlyObservers  map[string]struct{}
 	}
+
+	// Name is an event name that is just a string, so that events can be
+	// named without defining a Stringer type: On(Name("order.created")).
+	Name string
+
+	// eventJSON is the JSON form of an Event.
+	eventJSON struct {
+		ID        string            `json:"id"`
+		Name      string            `json:"name"`
+		Data      interface{}       `json:"data"`
+		Timestamp time.Time         `json:"timestamp"`
+		Headers   map[string]string `json:"headers,omitempty"`
+	}
+
+	// MatchDecision records whether a subscription matched an event, and if
+	// so, the first of its matchers that did.
+	MatchDecision struct {
+		SubscriptionID string
+		Matched        bool
+		Matcher        string
+	}
+
+	// MatchTrace lists the match decision of every subscription for a
+	// publish.
+	MatchTrace []MatchDecision
 )
 
-func newEvent(name Stringer, data interface{}) Event {
+// EventBusStarted is the name of the event published by WithStartupEventBusOpt.
+const EventBusStarted Name = "eventbus.started"
+
+func newEvent(name Stringer, data interface{}, now time.Time) Event {
 	return Event{
 		ID:        id.New(),
 		Name:      name,
 		Data:      data,
-		Timestamp: time.Now().UTC(),
+		Timestamp: now.UTC(),
+	}
+}
+
+func (n Name) String() string {
+	return string(n)
+}
+
+// MarshalJSON encodes the event with its name as a string. Publish options,
+// such as timeouts, are not encoded.
+func (e Event) MarshalJSON() ([]byte, error) {
+	j := eventJSON{
+		ID:        e.ID,
+		Data:      e.Data,
+		Timestamp: e.Timestamp,
+		Headers:   e.Headers,
 	}
+	if e.Name != nil {
+		j.Name = e.Name.String()
+	}
+	return json.Marshal(j)
+}
+
+// ParseEvent decodes an event encoded with MarshalJSON. Its name is a Name,
+// and its data is decoded as by encoding/json into an interface{}.
+func ParseEvent(data []byte) (Event, error) {
+	var j eventJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		ID:        j.ID,
+		Name:      Name(j.Name),
+		Data:      j.Data,
+		Timestamp: j.Timestamp,
+		Headers:   j.Headers,
+	}, nil
 }
@@ -0,0 +1,160 @@
+package eventbus_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+)
+
+func TestEvent_MarshalAndParse_RoundTrips(t *testing.T) {
+	sink := &memoryAuditSink{}
+	bus := eventbus.New(eventbus.WithAuditSinkBusOpt(sink))
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	if err := bus.Publish(context.Background(), testEvent, map[string]interface{}{"user": "alice", "age": 30.0},
+		eventbus.WithTimestampEventOpt(ts)); err != nil {
+		t.Fatal("expected no error", err)
+	}
+	e := sink.events[0]
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal("expected no error", err)
+	}
+	parsed, err := eventbus.ParseEvent(b)
+	if err != nil {
+		t.Fatal("expected no error", err)
+	}
+
+	if parsed.ID != e.ID {
+		t.Error("expected the ID to round trip", parsed.ID)
+	}
+	if parsed.Name != eventbus.Name(testEvent) || parsed.Name.String() != testEvent.String() {
+		t.Error("expected the name to be parsed as a Name", parsed.Name)
+	}
+	if !parsed.Timestamp.Equal(ts) {
+		t.Error("expected the timestamp to round trip", parsed.Timestamp)
+	}
+	data, ok := parsed.Data.(map[string]interface{})
+	if !ok || data["user"] != "alice" || data["age"] != 30.0 {
+		t.Error("expected the data to round trip", parsed.Data)
+	}
+}
+
+func TestEvent_Marshal_OmitsPublishOptions(t *testing.T) {
+	sink := &memoryAuditSink{}
+	bus := eventbus.New(eventbus.WithAuditSinkBusOpt(sink))
+	if err := bus.Publish(context.Background(), testEvent, nil,
+		eventbus.WithHandlerTimeoutEventOpt(time.Second),
+		eventbus.WithPublishTimeoutEventOpt(time.Second)); err != nil {
+		t.Fatal("expected no error", err)
+	}
+
+	b, err := json.Marshal(sink.events[0])
+	if err != nil {
+		t.Fatal("expected no error", err)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		t.Fatal("expected no error", err)
+	}
+	if len(fields) != 4 {
+		t.Error("expected only the id, name, data and timestamp", string(b))
+	}
+	if fields["name"] != testEvent.String() {
+		t.Error("expected the name as a string", fields["name"])
+	}
+}
+
+func TestParseEvent_InvalidJSON_ReturnsError(t *testing.T) {
+	if _, err := eventbus.ParseEvent([]byte("{")); err == nil {
+		t.Error("expected an error")
+	}
+	if _, err := eventbus.ParseEvent([]byte(`{"name": 1}`)); err == nil || !strings.Contains(err.Error(), "name") {
+		t.Error("expected an error for a name that isn't a string", err)
+	}
+}
+
+func TestPublish_WithHeaders_HandlerReadsThemFromContext(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var headers map[string]string
+	bus.On(testEvent).Do(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) error {
+		headers = eventbus.HeadersFromContext(ctx)
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil,
+		eventbus.WithHeaderEventOpt("correlation-id", "abc"),
+		eventbus.WithHeaderEventOpt("user-id", "42"),
+		eventbus.WithHandlerTimeoutEventOpt(time.Second),
+		eventbus.WithPublishTimeoutEventOpt(time.Second)); err != nil {
+		t.Error("expected no error", err)
+	}
+	if len(headers) != 2 || headers["correlation-id"] != "abc" || headers["user-id"] != "42" {
+		t.Error("expected both headers through the timeout contexts", headers)
+	}
+}
+
+func TestPublish_WithHeadersAndContextKeys_ObserverReadsThem(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithContextKeysBusOpt())
+	headers := make(chan map[string]string, 1)
+	bus.AddObserver(observerFunc(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) {
+		headers <- eventbus.HeadersFromContext(ctx)
+	}))
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithHeaderEventOpt("trace-id", "t1")); err != nil {
+		t.Error("expected no error", err)
+	}
+	if h := <-headers; h["trace-id"] != "t1" {
+		t.Error("expected the header to reach the observer", h)
+	}
+}
+
+func TestHeadersFromContext_NoHeaders_ReturnsNil(t *testing.T) {
+	if h := eventbus.HeadersFromContext(context.Background()); h != nil {
+		t.Error("expected no headers", h)
+	}
+}
+
+func TestEvent_MarshalAndParse_KeepsHeaders(t *testing.T) {
+	b, err := json.Marshal(eventbus.Event{Name: testEvent, Headers: map[string]string{"k": "v"}})
+	if err != nil {
+		t.Fatal("expected no error", err)
+	}
+	e, err := eventbus.ParseEvent(b)
+	if err != nil {
+		t.Fatal("expected no error", err)
+	}
+	if e.Headers["k"] != "v" {
+		t.Error("expected the headers to round trip", e.Headers)
+	}
+}
+
+func TestName_PublishAndSubscribe_MatchesEqualNames(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var got eventbus.Stringer
+	bus.On(eventbus.Name("order.created")).Do(func(_ context.Context, name eventbus.Stringer, _ interface{}) error {
+		got = name
+		return nil
+	})
+
+	if err := bus.Publish(ctx, eventbus.Name("order.created"), nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	if got != eventbus.Name("order.created") {
+		t.Error("expected the handler to receive the name", got)
+	}
+	if !eventbus.ExactMatcher(eventbus.Name("order.created")).Match(eventbus.Name("order.created"), nil) {
+		t.Error("expected equal names to match exactly")
+	}
+	if eventbus.ExactMatcher(eventbus.Name("order.created")).Match(EventName("order.created"), nil) {
+		t.Error("expected names of different types to not match exactly")
+	}
+}
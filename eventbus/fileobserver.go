@@ -0,0 +1,63 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileObserver appends the events it observes to a file as JSON lines.
+type fileObserver struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// FileObserver opens, or creates, the file at path and returns an observer that
+// appends each event it observes to it as a line of JSON, in the format of
+// Event.MarshalJSON, along with a function that closes the file. The lines
+// have the ID and timestamp of the published event; when the observer is
+// called outside a publish, they have no ID, and their timestamp is when the
+// event was observed. Add the observer with AddErrorObserver so that write
+// errors are reported like other observer errors.
+func FileObserver(path string) (ErrorObserver, func() error, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	o := &fileObserver{file: f}
+	return o, o.close, nil
+}
+
+func (o *fileObserver) Observe(ctx context.Context, name Stringer, data interface{}) error {
+	e, ok := eventFromContext(ctx)
+	if !ok {
+		e = Event{Timestamp: time.Now().UTC(), Headers: HeadersFromContext(ctx)}
+	}
+	line, err := json.Marshal(Event{
+		ID:        e.ID,
+		Name:      name,
+		Data:      data,
+		Timestamp: e.Timestamp,
+		Headers:   e.Headers,
+	})
+	if err != nil {
+		return fmt.Errorf("file observer: %w", err)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, err := o.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("file observer: %w", err)
+	}
+	return nil
+}
+
+func (o *fileObserver) close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.file.Close()
+}
@@ -0,0 +1,94 @@
+package eventbus_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+)
+
+func TestFileObserver_SeveralEvents_AppendedAsJSONLinesInOrder(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	o, closeFile, err := eventbus.FileObserver(path)
+	if err != nil {
+		t.Fatal("expected no error", err)
+	}
+	clock := newFakeClock()
+	bus := eventbus.New(eventbus.WithClockBusOpt(clock))
+	bus.AddErrorObserver(o)
+
+	names := []string{"first", "second", "third"}
+	for i, name := range names {
+		if err := bus.Publish(ctx, EventName(name), float64(i)); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+	if err := closeFile(); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal("expected no error", err)
+	}
+	defer f.Close()
+	var events []eventbus.Event
+	for s := bufio.NewScanner(f); s.Scan(); {
+		e, err := eventbus.ParseEvent(s.Bytes())
+		if err != nil {
+			t.Fatal("expected a JSON line", s.Text(), err)
+		}
+		events = append(events, e)
+	}
+
+	if len(events) != len(names) {
+		t.Fatal("expected a line per event", events)
+	}
+	ids := make(map[string]bool)
+	for i, e := range events {
+		if e.Name.String() != names[i] || e.Data != float64(i) {
+			t.Error("expected the events in order", e)
+		}
+		if e.ID == "" || ids[e.ID] {
+			t.Error("expected the ID of the published event", e.ID)
+		}
+		ids[e.ID] = true
+		if !e.Timestamp.Equal(clock.Now()) {
+			t.Error("expected the timestamp of the published event", e.Timestamp)
+		}
+	}
+}
+
+func TestFileObserver_WriteAfterClose_ReportsObserverError(t *testing.T) {
+	ctx := context.Background()
+	o, closeFile, err := eventbus.FileObserver(filepath.Join(t.TempDir(), "events.jsonl"))
+	if err != nil {
+		t.Fatal("expected no error", err)
+	}
+	bus := eventbus.New()
+	bus.AddErrorObserver(o)
+	_ = closeFile()
+
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, os.ErrClosed) {
+		t.Error("expected the write error", err)
+	}
+	select {
+	case err := <-bus.ObserverErrors():
+		if !errors.Is(err, os.ErrClosed) {
+			t.Error("expected the write error", err)
+		}
+	default:
+		t.Error("expected the error on the observer errors channel")
+	}
+}
+
+func TestFileObserver_InvalidPath_ReturnsError(t *testing.T) {
+	if _, _, err := eventbus.FileObserver(filepath.Join(t.TempDir(), "missing", "events.jsonl")); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,57 @@
+package eventbus
+
+import (
+	"context"
+	"time"
+)
+
+// Flushes the bus every interval until ctx is done, calling cb with the number
+// of publishes that completed since the previous flush.
+func (b *bus) AutoFlush(ctx context.Context, interval time.Duration, cb func(flushed int)) {
+	t := b.clock.NewTicker(interval)
+	last := b.completed.Load()
+	go func() {
+		defer t.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C():
+				b.Flush(ctx)
+				n := b.completed.Load()
+				cb(int(n - last))
+				last = n
+			}
+		}
+	}()
+}
+
+// Waits until no publish has started or finished for idle, and none is in
+// flight, which is useful to drain bursty producers. The bus is checked a few
+// times per idle period on its clock. Returns the error of ctx if it is done
+// first.
+func (b *bus) FlushIdle(ctx context.Context, idle time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	interval := idle / 4
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	t := b.clock.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		if since, ok := b.idleSince(); ok && b.clock.Now().Sub(since) >= idle {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C():
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+)
+
+func TestAutoFlush_ClockAdvanced_ReportsCompletedPublishes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock := newFakeClock()
+	bus := eventbus.New(eventbus.WithClockBusOpt(clock))
+
+	flushed := make(chan int)
+	bus.AutoFlush(ctx, time.Second, func(n int) {
+		flushed <- n
+	})
+
+	expectFlushed := func(expected int) {
+		t.Helper()
+		clock.Advance(time.Second)
+		select {
+		case n := <-flushed:
+			if n != expected {
+				t.Error("expected flushed count", expected, n)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("expected callback to be called")
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := bus.Publish(ctx, testEvent, nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+	expectFlushed(2)
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	expectFlushed(1)
+	expectFlushed(0)
+}
+
+func TestAutoFlush_ContextDone_StopsCallingBack(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	clock := newFakeClock()
+	bus := eventbus.New(eventbus.WithClockBusOpt(clock))
+
+	called := make(chan int, 1)
+	bus.AutoFlush(ctx, time.Second, func(n int) {
+		called <- n
+	})
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	clock.Advance(time.Second)
+
+	select {
+	case <-called:
+		t.Error("expected callback to not be called after the context is done")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestFlushIdle_PublishedIntermittently_ReturnsAfterIdlePeriod(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	bus := eventbus.New(eventbus.WithClockBusOpt(clock))
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error { return nil })
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- bus.FlushIdle(ctx, 100*time.Millisecond)
+	}()
+	clock.waitForTickers(1)
+
+	clock.Advance(50 * time.Millisecond)
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	clock.Advance(50 * time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatal("expected FlushIdle to wait for the idle period after the last publish, got", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	clock.Advance(60 * time.Millisecond)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error("expected no error, got", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected FlushIdle to return after the idle period")
+	}
+}
+
+func TestFlushIdle_PublishInFlight_WaitsUntilContextDone(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	release := make(chan struct{})
+	defer close(release)
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		<-release
+		return nil
+	})
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithAsyncEventOpt()); err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if err := bus.FlushIdle(ctx, time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected the deadline to be exceeded, got", err)
+	}
+}
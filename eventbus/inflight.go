@@ -0,0 +1,68 @@
+package eventbus
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// InFlightPublish describes a publish that is currently executing.
+type InFlightPublish struct {
+	EventID string
+	Name    Stringer
+	Started time.Time
+	// Cancel cancels the context of the publish and its handlers.
+	Cancel context.CancelFunc
+}
+
+// Returns the publishes that are currently executing, oldest first.
+func (b *bus) InFlight() []InFlightPublish {
+	b.inFlightMu.Lock()
+	defer b.inFlightMu.Unlock()
+
+	publishes := make([]InFlightPublish, 0, len(b.inFlight))
+	for _, p := range b.inFlight {
+		publishes = append(publishes, p)
+	}
+	sort.Slice(publishes, func(i, j int) bool {
+		return publishes[i].Started.Before(publishes[j].Started)
+	})
+
+	return publishes
+}
+
+// track records the publish of the event as in flight until the returned
+// function is called.
+func (b *bus) track(e Event, cancel context.CancelFunc) func() {
+	b.inFlightMu.Lock()
+	defer b.inFlightMu.Unlock()
+
+	now := b.clock.Now()
+	b.inFlight[e.ID] = InFlightPublish{
+		EventID: e.ID,
+		Name:    e.Name,
+		Started: now,
+		Cancel:  cancel,
+	}
+	b.lastActive = now
+
+	return func() {
+		b.inFlightMu.Lock()
+		defer b.inFlightMu.Unlock()
+
+		delete(b.inFlight, e.ID)
+		b.lastActive = b.clock.Now()
+	}
+}
+
+// idleSince returns when the last publish started or finished, and false if a
+// publish is in flight or waiting to start.
+func (b *bus) idleSince() (time.Time, bool) {
+	b.inFlightMu.Lock()
+	defer b.inFlightMu.Unlock()
+
+	if len(b.inFlight) > 0 || b.pending.Load() > 0 {
+		return time.Time{}, false
+	}
+	return b.lastActive, true
+}
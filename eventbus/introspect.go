@@ -0,0 +1,70 @@
+package eventbus
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Returns the number of registered subscriptions.
+func (b *bus) SubscriptionCount() int {
+	n := 0
+	for _, subs := range b.load().subscriptions {
+		n += len(subs)
+	}
+	return n
+}
+
+// Returns the number of registered observers.
+func (b *bus) ObserverCount() int {
+	return len(b.load().observers)
+}
+
+// Returns the number of registered subscriptions by what they match.
+// Subscriptions made with On are counted under their event name, case-folded
+// with WithCaseInsensitiveNamesBusOpt; those made with When under their
+// matchers, joined with " || ".
+func (b *bus) Subscriptions() map[string]int {
+	counts := make(map[string]int)
+	for key, subs := range b.load().subscriptions {
+		if k, ok := key.(noMatch); ok && strings.HasPrefix(string(k), "id:") {
+			for _, s := range subs {
+				c := s.load()
+				matchers := make([]string, len(c.matchers))
+				for i, m := range c.matchers {
+					matchers[i] = m.String()
+				}
+				counts[strings.Join(matchers, " || ")]++
+			}
+			continue
+		}
+
+		counts[key.String()] += len(subs)
+	}
+	return counts
+}
+
+// Returns the IDs of the subscriptions that haven't matched a published event
+// within the window, measured on the bus clock, in registration order. A
+// subscription registered less than window ago isn't reported until it has
+// had the whole window to match.
+func (b *bus) DeadSubscriptions(window time.Duration) []string {
+	cutoff := b.clock.Now().Add(-window).UnixNano()
+	var dead []*subscription
+	for _, subs := range b.load().subscriptions {
+		for _, s := range subs {
+			if s.active.Load() <= cutoff {
+				dead = append(dead, s)
+			}
+		}
+	}
+	sort.Slice(dead, func(i, j int) bool {
+		return dead[i].seq.Load() < dead[j].seq.Load()
+	})
+
+	ids := make([]string, len(dead))
+	for i, s := range dead {
+		ids[i] = s.id
+	}
+	return ids
+}
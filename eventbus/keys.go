@@ -0,0 +1,68 @@
+package eventbus
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+type (
+	// Keys names an event by a set of key/value dimensions, like labels, for
+	// example service, region and severity. Publish with PublishKeys and
+	// subscribe with OnKeys; a Keys value can't be used with On.
+	Keys map[string]string
+
+	keysMatcher Keys
+)
+
+// String returns the pairs as "key=value", sorted by key and separated by
+// commas.
+func (k Keys) String() string {
+	pairs := make([]string, 0, len(k))
+	for key, value := range k {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (k Keys) clone() Keys {
+	c := make(Keys, len(k))
+	for key, value := range k {
+		c[key] = value
+	}
+	return c
+}
+
+// Match returns true if the event was published with Keys that include all of
+// the matcher's pairs.
+func (m keysMatcher) Match(name Stringer, data interface{}) bool {
+	keys, ok := name.(Keys)
+	if !ok {
+		return false
+	}
+
+	for key, value := range m {
+		if v, ok := keys[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (m keysMatcher) String() string {
+	return "{" + Keys(m).String() + "}"
+}
+
+// Subscribes to events published with PublishKeys whose keys include all of the
+// provided pairs.
+func (b *bus) OnKeys(keys map[string]string, fn Handler) *subscription {
+	s := b.When(keysMatcher(Keys(keys).clone()))
+	s.Do(fn)
+	return s
+}
+
+// Publishes an event named by the provided keys.
+func (b *bus) PublishKeys(ctx context.Context, keys map[string]string, data interface{}, opts ...eventOpt) error {
+	return b.Publish(ctx, Keys(keys).clone(), data, opts...)
+}
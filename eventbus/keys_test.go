@@ -0,0 +1,53 @@
+package eventbus_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+)
+
+func TestOnKeys_PublishedKeysIncludeSubset_CallsHandler(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var received eventbus.Stringer
+	bus.OnKeys(map[string]string{"service": "billing", "severity": "high"}, func(_ context.Context, name eventbus.Stringer, _ interface{}) error {
+		received = name
+		return nil
+	})
+
+	keys := map[string]string{"service": "billing", "region": "eu", "severity": "high"}
+	if err := bus.PublishKeys(ctx, keys, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if received == nil || received.String() != "region=eu,service=billing,severity=high" {
+		t.Error("expected handler to receive the published keys", received)
+	}
+}
+
+func TestOnKeys_PublishedKeyDiffers_DoesNotCallHandler(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	called := false
+	bus.OnKeys(map[string]string{"service": "billing", "severity": "high"}, func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called = true
+		return nil
+	})
+
+	for _, keys := range []map[string]string{
+		{"service": "billing", "severity": "low"},
+		{"service": "billing"},
+	} {
+		if err := bus.PublishKeys(ctx, keys, nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+	if err := bus.Publish(ctx, EventName("service=billing,severity=high"), nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if called {
+		t.Error("expected handler to not be called")
+	}
+}
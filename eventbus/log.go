@@ -0,0 +1,37 @@
+package eventbus
+
+import (
+	"context"
+
+	"github.com/almahoozi/go-eventbus/pkg/log"
+	"golang.org/x/exp/slog"
+)
+
+// log writes a trace record to the bus logger, or through pkg/log if none was
+// configured with WithLoggerBusOpt.
+func (b *bus) log(ctx context.Context, msg string, args ...interface{}) {
+	if b.logger == nil {
+		log.Log(ctx, msg, args...)
+		return
+	}
+	b.logger.Log(ctx, slog.Level(log.LogLevel), msg, args...)
+}
+
+// warn is like log, but records a warning, which is on by default unlike trace
+// records.
+func (b *bus) warn(ctx context.Context, msg string, args ...interface{}) {
+	if b.logger == nil {
+		slog.Log(ctx, slog.LevelWarn, msg, args...)
+		return
+	}
+	b.logger.Log(ctx, slog.LevelWarn, msg, args...)
+}
+
+// logErr is like log, but records an error.
+func (b *bus) logErr(ctx context.Context, msg string, args ...interface{}) {
+	if b.logger == nil {
+		log.LogErr(ctx, msg, args...)
+		return
+	}
+	b.logger.Log(ctx, slog.Level(log.ErrLevel), msg, args...)
+}
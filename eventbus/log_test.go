@@ -0,0 +1,133 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+	"golang.org/x/exp/slog"
+)
+
+// captureHandler is a slog.Handler that records every message at every level.
+// The level of a record is kept with its attributes, under slog.LevelKey.
+type captureHandler struct {
+	mu       sync.Mutex
+	messages []string
+	attrs    []map[string]string
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := map[string]string{slog.LevelKey: r.Level.String()}
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.String()
+		return true
+	})
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.messages = append(h.messages, r.Message)
+	h.attrs = append(h.attrs, attrs)
+	return nil
+}
+
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *captureHandler) WithGroup(string) slog.Handler {
+	return h
+}
+
+// find returns the attributes of every record with the message.
+func (h *captureHandler) find(msg string) []map[string]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var found []map[string]string
+	for i, m := range h.messages {
+		if m == msg {
+			found = append(found, h.attrs[i])
+		}
+	}
+	return found
+}
+
+// captureDefaultLog replaces the default slog logger for the duration of the
+// test.
+func captureDefaultLog(t *testing.T) *captureHandler {
+	h := &captureHandler{}
+	previous := slog.Default()
+	slog.SetDefault(slog.New(h))
+	t.Cleanup(func() {
+		slog.SetDefault(previous)
+	})
+	return h
+}
+
+func TestPublish_WithLogger_TracesEventEndToEnd(t *testing.T) {
+	ctx := context.Background()
+	logs := &captureHandler{}
+	bus := eventbus.New(eventbus.WithLoggerBusOpt(slog.New(logs)))
+	s := bus.On(testEvent)
+	s.Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		return errors.New("failed")
+	})
+
+	_ = bus.Publish(ctx, testEvent, nil)
+	bus.Close()
+
+	published := logs.find("event published")
+	if len(published) != 1 || published[0]["name"] != testEvent.String() {
+		t.Fatal("expected the publish to be logged", logs.messages)
+	}
+	id := published[0]["event"]
+
+	invoked := logs.find("subscription invoked")
+	if len(invoked) != 1 || invoked[0]["event"] != id || invoked[0]["subscription"] != s.String() {
+		t.Error("expected the invocation to be logged with event and subscription IDs", invoked)
+	}
+	failed := logs.find("handler failed")
+	if len(failed) != 1 || failed[0]["event"] != id || failed[0]["error"] != "failed" {
+		t.Error("expected the handler error to be logged", failed)
+	}
+	if len(logs.find("bus closed")) != 1 {
+		t.Error("expected the close to be logged", logs.messages)
+	}
+}
+
+func TestPublish_WithLogger_DoesNotUseDefaultLogger(t *testing.T) {
+	ctx := context.Background()
+	defaults := captureDefaultLog(t)
+	bus := eventbus.New(eventbus.WithLoggerBusOpt(slog.New(&captureHandler{})))
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if len(defaults.messages) != 0 {
+		t.Error("expected nothing to be logged to the default logger", defaults.messages)
+	}
+}
+
+func TestObserver_TimesOut_LogsTimeout(t *testing.T) {
+	ctx := context.Background()
+	logs := &captureHandler{}
+	bus := eventbus.New(eventbus.WithLoggerBusOpt(slog.New(logs)))
+	bus.AddObserver(observerFunc(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) {
+		<-ctx.Done()
+	}), eventbus.WithTimeoutObserverOpt(time.Millisecond))
+
+	_ = bus.Publish(ctx, testEvent, nil)
+	bus.Flush(ctx)
+
+	if len(logs.find("observer timed out")) != 1 {
+		t.Error("expected the observer timeout to be logged", logs.messages)
+	}
+}
@@ -1,8 +1,13 @@
 package eventbus
 
 import (
+	"context"
+	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 type (
@@ -11,16 +16,64 @@ type (
 		Match(Stringer, interface{}) bool
 		String() string
 	}
+	// ContextMatcher is a Matcher that can also match on the context of the
+	// publish, for example to only match events of a tenant. Subscriptions
+	// call MatchContext with the publish context instead of Match, including
+	// within And, Or and Not. Where there is no publish, such as in
+	// subscription.Match, it gets a background context.
+	ContextMatcher interface {
+		Matcher
+		MatchContext(ctx context.Context, name Stringer, data interface{}) bool
+	}
 	regexMatcher struct {
 		str   string
 		regex *regexp.Regexp
 	}
+	lazyRegexMatcher struct {
+		str   string
+		once  sync.Once
+		regex *regexp.Regexp
+	}
+	instrumentedMatcher struct {
+		Matcher
+		matched   atomic.Int64
+		evaluated atomic.Int64
+	}
 	// PredicateMatcher is a function that accepts an event name and data and returns true if the
 	// event matches the predicate.
 	PredicateMatcher func(Stringer, interface{}) bool
 	// StringMatcher is a string that matches events by name, ignoring case and type.
 	StringMatcher string
-	noMatch       string
+	// PrefixMatcher is a string that matches events whose name starts with it.
+	// Matching is case-sensitive.
+	PrefixMatcher string
+	// SuffixMatcher is a string that matches events whose name ends with it.
+	// Matching is case-sensitive.
+	SuffixMatcher string
+	// PrefixFoldMatcher is a PrefixMatcher that ignores case.
+	PrefixFoldMatcher string
+	// SuffixFoldMatcher is a SuffixMatcher that ignores case.
+	SuffixFoldMatcher string
+	// AllMatcher matches every event.
+	AllMatcher struct{}
+	// ErrorMatcher matches events whose data is an error.
+	ErrorMatcher struct{}
+	andMatcher   []Matcher
+	orMatcher    []Matcher
+	notMatcher   struct {
+		matcher Matcher
+	}
+	sizeMatcher struct {
+		min, max int
+	}
+	hasFieldMatcher struct {
+		path string
+		keys []string
+	}
+	dataMatcher[T any] struct {
+		pred func(T) bool
+	}
+	noMatch string
 )
 
 func (m noMatch) String() string {
@@ -31,9 +84,13 @@ func (m noMatch) String() string {
 // It can match all events with "*", all events with a prefix "foo*", all events
 // with a suffix "*bar", all events with a substring "foo*bar", or a combination
 // of the above. A question mark (?) can be used to match a single character.
+// The pattern must match the whole event name, and all other characters are
+// matched literally.
 func WildcardMatcher(s string) regexMatcher {
-	s = strings.ReplaceAll(s, "*", ".*")
-	s = strings.ReplaceAll(s, "?", ".?")
+	s = regexp.QuoteMeta(s)
+	s = strings.ReplaceAll(s, `\*`, ".*")
+	s = strings.ReplaceAll(s, `\?`, ".")
+	s = "^" + s + "$"
 	return regexMatcher{
 		str:   s,
 		regex: regexp.MustCompile(s),
@@ -60,6 +117,49 @@ func (m regexMatcher) String() string {
 	return m.str
 }
 
+// LazyRegexMatcher is like RegexMatcher, but defers compiling the regular
+// expression until the first call to Match. A pattern that fails to compile
+// never matches.
+func LazyRegexMatcher(s string) Matcher {
+	return &lazyRegexMatcher{str: s}
+}
+
+func (m *lazyRegexMatcher) Match(name Stringer, data interface{}) bool {
+	m.once.Do(func() {
+		m.regex, _ = regexp.Compile(m.str)
+	})
+
+	return m.regex != nil && m.regex.MatchString(name.String())
+}
+
+func (m *lazyRegexMatcher) String() string {
+	return m.str
+}
+
+// InstrumentedMatcher wraps a matcher to count how many times it was evaluated
+// and how many of those evaluations matched. The returned function reports
+// the current counts.
+func InstrumentedMatcher(m Matcher) (Matcher, func() (matched, evaluated int64)) {
+	im := &instrumentedMatcher{Matcher: m}
+	return im, func() (int64, int64) {
+		return im.matched.Load(), im.evaluated.Load()
+	}
+}
+
+func (m *instrumentedMatcher) Match(name Stringer, data interface{}) bool {
+	return m.MatchContext(context.Background(), name, data)
+}
+
+func (m *instrumentedMatcher) MatchContext(ctx context.Context, name Stringer, data interface{}) bool {
+	m.evaluated.Add(1)
+	if !matchContext(ctx, m.Matcher, name, data) {
+		return false
+	}
+
+	m.matched.Add(1)
+	return true
+}
+
 func (m PredicateMatcher) Match(name Stringer, data interface{}) bool {
 	return m(name, data)
 }
@@ -69,16 +169,275 @@ func (m PredicateMatcher) String() string {
 }
 
 func (m StringMatcher) Match(name Stringer, data interface{}) bool {
-	return strings.EqualFold(name.String(), name.String())
+	return strings.EqualFold(string(m), name.String())
 }
 
 func (m StringMatcher) String() string {
 	return string(m)
 }
 
+func (m PrefixMatcher) Match(name Stringer, data interface{}) bool {
+	return strings.HasPrefix(name.String(), string(m))
+}
+
+func (m PrefixMatcher) String() string {
+	return string(m) + "*"
+}
+
+func (m SuffixMatcher) Match(name Stringer, data interface{}) bool {
+	return strings.HasSuffix(name.String(), string(m))
+}
+
+func (m SuffixMatcher) String() string {
+	return "*" + string(m)
+}
+
+func (m PrefixFoldMatcher) Match(name Stringer, data interface{}) bool {
+	n := name.String()
+	return len(n) >= len(m) && strings.EqualFold(n[:len(m)], string(m))
+}
+
+func (m PrefixFoldMatcher) String() string {
+	return "(?i)" + string(m) + "*"
+}
+
+func (m SuffixFoldMatcher) Match(name Stringer, data interface{}) bool {
+	n := name.String()
+	return len(n) >= len(m) && strings.EqualFold(n[len(n)-len(m):], string(m))
+}
+
+func (m SuffixFoldMatcher) String() string {
+	return "(?i)*" + string(m)
+}
+
+func (AllMatcher) Match(name Stringer, data interface{}) bool {
+	return true
+}
+
+func (AllMatcher) String() string {
+	return "*"
+}
+
+func (ErrorMatcher) Match(name Stringer, data interface{}) bool {
+	_, ok := data.(error)
+	return ok
+}
+
+func (ErrorMatcher) String() string {
+	return "error"
+}
+
+// matchContext matches the event with m, passing ctx if m is a ContextMatcher.
+func matchContext(ctx context.Context, m Matcher, name Stringer, data interface{}) bool {
+	if cm, ok := m.(ContextMatcher); ok {
+		return cm.MatchContext(ctx, name, data)
+	}
+	return m.Match(name, data)
+}
+
+// AndMatcher matches events that all of the provided matchers match.
+func AndMatcher(matchers ...Matcher) Matcher {
+	return andMatcher(matchers)
+}
+
+func (m andMatcher) Match(name Stringer, data interface{}) bool {
+	return m.MatchContext(context.Background(), name, data)
+}
+
+func (m andMatcher) MatchContext(ctx context.Context, name Stringer, data interface{}) bool {
+	for _, matcher := range m {
+		if !matchContext(ctx, matcher, name, data) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m andMatcher) String() string {
+	strs := make([]string, len(m))
+	for i, matcher := range m {
+		strs[i] = matcher.String()
+	}
+	return "(" + strings.Join(strs, " && ") + ")"
+}
+
+// Or matches events that any of the provided matchers match. Its String is
+// the children joined with " || " in parentheses.
+func Or(matchers ...Matcher) Matcher {
+	return orMatcher(matchers)
+}
+
+func (m orMatcher) Match(name Stringer, data interface{}) bool {
+	return m.MatchContext(context.Background(), name, data)
+}
+
+func (m orMatcher) MatchContext(ctx context.Context, name Stringer, data interface{}) bool {
+	for _, matcher := range m {
+		if matchContext(ctx, matcher, name, data) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m orMatcher) String() string {
+	strs := make([]string, len(m))
+	for i, matcher := range m {
+		strs[i] = matcher.String()
+	}
+	return "(" + strings.Join(strs, " || ") + ")"
+}
+
+// And is the same as AndMatcher; its String is the children joined with
+// " && " in parentheses.
+func And(matchers ...Matcher) Matcher {
+	return AndMatcher(matchers...)
+}
+
+// Not is the same as NotMatcher; its String is the inner matcher's prefixed
+// with "!".
+func Not(m Matcher) Matcher {
+	return NotMatcher(m)
+}
+
+// NotMatcher matches events that the provided matcher doesn't match.
+func NotMatcher(m Matcher) Matcher {
+	return notMatcher{matcher: m}
+}
+
+func (m notMatcher) Match(name Stringer, data interface{}) bool {
+	return m.MatchContext(context.Background(), name, data)
+}
+
+func (m notMatcher) MatchContext(ctx context.Context, name Stringer, data interface{}) bool {
+	return !matchContext(ctx, m.matcher, name, data)
+}
+
+func (m notMatcher) String() string {
+	return "!" + m.matcher.String()
+}
+
+// SizeMatcher matches events whose data has a size within [min, max]. Sizes
+// are known for []byte, strings, and data with a Len or Size method; other data
+// never matches.
+func SizeMatcher(min, max int) Matcher {
+	return sizeMatcher{min: min, max: max}
+}
+
+func (m sizeMatcher) Match(name Stringer, data interface{}) bool {
+	var size int64
+	switch data := data.(type) {
+	case []byte:
+		size = int64(len(data))
+	case string:
+		size = int64(len(data))
+	case interface{ Len() int }:
+		size = int64(data.Len())
+	case interface{ Size() int }:
+		size = int64(data.Size())
+	case interface{ Size() int64 }:
+		size = data.Size()
+	default:
+		return false
+	}
+	return size >= int64(m.min) && size <= int64(m.max)
+}
+
+func (m sizeMatcher) String() string {
+	return "size[" + strconv.Itoa(m.min) + "," + strconv.Itoa(m.max) + "]"
+}
+
+// HasFieldMatcher matches events whose data has a value, of any kind, at the
+// dotted path, such as "user.address.city". Each key is looked up in a map
+// with string keys, or in a struct by field name or JSON name; pointers and
+// interfaces are followed. A nil map, pointer or interface on the way means
+// the path doesn't exist, but a nil value at its end does.
+func HasFieldMatcher(path string) Matcher {
+	return hasFieldMatcher{path: path, keys: strings.Split(path, ".")}
+}
+
+func (m hasFieldMatcher) Match(name Stringer, data interface{}) bool {
+	_, ok := lookupField(reflect.ValueOf(data), m.keys)
+	return ok
+}
+
+func (m hasFieldMatcher) String() string {
+	return "has(" + m.path + ")"
+}
+
+// lookupField returns the value at the path of keys within v.
+func lookupField(v reflect.Value, keys []string) (reflect.Value, bool) {
+	for _, key := range keys {
+		for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+
+		switch v.Kind() {
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return reflect.Value{}, false
+			}
+			v = v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))
+			if !v.IsValid() {
+				return reflect.Value{}, false
+			}
+		case reflect.Struct:
+			f, ok := structField(v.Type(), key)
+			if !ok {
+				return reflect.Value{}, false
+			}
+			v = v.FieldByIndex(f.Index)
+		default:
+			return reflect.Value{}, false
+		}
+	}
+	return v, true
+}
+
+// structField returns the exported field of t with the name or JSON name key.
+func structField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag == key || f.Name == key {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// DataMatcher matches events whose data is a T that satisfies pred. Events
+// with data of another type don't match. Its String is "data(T)".
+func DataMatcher[T any](pred func(T) bool) Matcher {
+	return dataMatcher[T]{pred: pred}
+}
+
+func (m dataMatcher[T]) Match(name Stringer, data interface{}) bool {
+	v, ok := data.(T)
+	return ok && m.pred(v)
+}
+
+func (m dataMatcher[T]) String() string {
+	return "data(" + reflect.TypeOf((*T)(nil)).Elem().String() + ")"
+}
+
 // ExactMatcher is matcher that matches events by equality.
 func ExactMatcher(thisName Stringer) PredicateMatcher {
 	return func(otherName Stringer, data interface{}) bool {
 		return thisName == otherName
 	}
 }
+
+// exactFoldMatcher is like ExactMatcher, but compares the names as strings,
+// ignoring case.
+func exactFoldMatcher(thisName Stringer) PredicateMatcher {
+	this := thisName.String()
+	return func(otherName Stringer, data interface{}) bool {
+		return strings.EqualFold(this, otherName.String())
+	}
+}
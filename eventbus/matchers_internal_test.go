@@ -0,0 +1,26 @@
+package eventbus
+
+import "testing"
+
+func TestLazyRegexMatcher_NeverMatched_DoesNotCompile(t *testing.T) {
+	m := LazyRegexMatcher("^foo$").(*lazyRegexMatcher)
+
+	if m.regex != nil {
+		t.Error("expected regex to not be compiled before the first match")
+	}
+}
+
+func TestLazyRegexMatcher_Matched_CompilesOnce(t *testing.T) {
+	m := LazyRegexMatcher("^foo$").(*lazyRegexMatcher)
+
+	m.Match(noMatch("foo"), nil)
+	compiled := m.regex
+	if compiled == nil {
+		t.Fatal("expected regex to be compiled after the first match")
+	}
+
+	m.Match(noMatch("bar"), nil)
+	if m.regex != compiled {
+		t.Error("expected regex to be compiled only once")
+	}
+}
@@ -0,0 +1,735 @@
+package eventbus_test
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+)
+
+func TestLazyRegexMatcher_MatchesLikeRegexMatcher(t *testing.T) {
+	m := eventbus.LazyRegexMatcher("^user\\.(created|deleted)$")
+
+	if !m.Match(EventName("user.created"), nil) {
+		t.Error("expected user.created to match")
+	}
+	if !m.Match(EventName("user.deleted"), nil) {
+		t.Error("expected user.deleted to match")
+	}
+	if m.Match(EventName("user.updated"), nil) {
+		t.Error("expected user.updated to not match")
+	}
+	if m.String() != "^user\\.(created|deleted)$" {
+		t.Error("expected String to return the pattern", m.String())
+	}
+}
+
+func TestLazyRegexMatcher_InvalidPattern_NeverMatches(t *testing.T) {
+	m := eventbus.LazyRegexMatcher("(")
+
+	if m.Match(EventName("("), nil) {
+		t.Error("expected invalid pattern to not match")
+	}
+}
+
+func TestPrefixMatcher_MatchesNamesWithPrefix(t *testing.T) {
+	m := eventbus.PrefixMatcher("order.")
+
+	if !m.Match(EventName("order.created"), nil) {
+		t.Error("expected order.created to match")
+	}
+	if m.Match(EventName("user.created"), nil) {
+		t.Error("expected user.created to not match")
+	}
+	if m.Match(EventName("Order.created"), nil) {
+		t.Error("expected matching to be case-sensitive")
+	}
+	if m.String() != "order.*" {
+		t.Error("expected String to render as a wildcard", m.String())
+	}
+}
+
+func TestSuffixMatcher_MatchesNamesWithSuffix(t *testing.T) {
+	m := eventbus.SuffixMatcher(".created")
+
+	if !m.Match(EventName("order.created"), nil) {
+		t.Error("expected order.created to match")
+	}
+	if m.Match(EventName("order.deleted"), nil) {
+		t.Error("expected order.deleted to not match")
+	}
+	if m.Match(EventName("order.Created"), nil) {
+		t.Error("expected matching to be case-sensitive")
+	}
+	if m.String() != "*.created" {
+		t.Error("expected String to render as a wildcard", m.String())
+	}
+}
+
+func TestPrefixMatcher_Empty_MatchesEverything(t *testing.T) {
+	m := eventbus.PrefixMatcher("")
+
+	if !m.Match(EventName("order.created"), nil) {
+		t.Error("expected order.created to match")
+	}
+	if !m.Match(EventName(""), nil) {
+		t.Error("expected an empty name to match")
+	}
+}
+
+func TestSuffixMatcher_Empty_MatchesEverything(t *testing.T) {
+	m := eventbus.SuffixMatcher("")
+
+	if !m.Match(EventName("order.created"), nil) {
+		t.Error("expected order.created to match")
+	}
+}
+
+func TestPrefixFoldMatcher_MatchesNamesWithPrefixIgnoringCase(t *testing.T) {
+	m := eventbus.PrefixFoldMatcher("order.")
+
+	if !m.Match(EventName("Order.Created"), nil) {
+		t.Error("expected Order.Created to match")
+	}
+	if m.Match(EventName("user.created"), nil) {
+		t.Error("expected user.created to not match")
+	}
+	if m.Match(EventName("ord"), nil) {
+		t.Error("expected a name shorter than the prefix to not match")
+	}
+	if m.String() != "(?i)order.*" {
+		t.Error("expected String to render as a case-insensitive wildcard", m.String())
+	}
+}
+
+func TestSuffixFoldMatcher_MatchesNamesWithSuffixIgnoringCase(t *testing.T) {
+	m := eventbus.SuffixFoldMatcher(".created")
+
+	if !m.Match(EventName("ORDER.CREATED"), nil) {
+		t.Error("expected ORDER.CREATED to match")
+	}
+	if m.Match(EventName("order.deleted"), nil) {
+		t.Error("expected order.deleted to not match")
+	}
+	if m.Match(EventName("ted"), nil) {
+		t.Error("expected a name shorter than the suffix to not match")
+	}
+	if m.String() != "(?i)*.created" {
+		t.Error("expected String to render as a case-insensitive wildcard", m.String())
+	}
+}
+
+func BenchmarkPrefixMatcher(b *testing.B) {
+	m := eventbus.PrefixMatcher("order.")
+	name := EventName("order.created")
+	for i := 0; i < b.N; i++ {
+		m.Match(name, nil)
+	}
+}
+
+func BenchmarkPrefixMatcher_Wildcard(b *testing.B) {
+	m := eventbus.WildcardMatcher("order.*")
+	name := EventName("order.created")
+	for i := 0; i < b.N; i++ {
+		m.Match(name, nil)
+	}
+}
+
+func BenchmarkSuffixMatcher(b *testing.B) {
+	m := eventbus.SuffixMatcher(".created")
+	name := EventName("order.created")
+	for i := 0; i < b.N; i++ {
+		m.Match(name, nil)
+	}
+}
+
+func BenchmarkSuffixMatcher_Wildcard(b *testing.B) {
+	m := eventbus.WildcardMatcher("*.created")
+	name := EventName("order.created")
+	for i := 0; i < b.N; i++ {
+		m.Match(name, nil)
+	}
+}
+
+func TestInstrumentedMatcher_EventsPublished_CountsEvaluationsAndMatches(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	m, counts := eventbus.InstrumentedMatcher(eventbus.PrefixMatcher("order."))
+	bus.When(m).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		return nil
+	})
+
+	for _, name := range []EventName{"order.created", "user.created", "order.deleted", "user.deleted", "other"} {
+		if err := bus.Publish(ctx, name, nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	matched, evaluated := counts()
+	if matched != 2 || evaluated != 5 {
+		t.Error("expected 2 matches out of 5 evaluations", matched, evaluated)
+	}
+	if m.String() != "order.*" {
+		t.Error("expected String of the wrapped matcher", m.String())
+	}
+}
+
+func TestInstrumentedMatcher_WithMatchTraceAndReport_EvaluatedOncePerPublish(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	m, counts := eventbus.InstrumentedMatcher(eventbus.PrefixMatcher("te"))
+	s := bus.When(m)
+	s.Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		return nil
+	})
+
+	var trace eventbus.MatchTrace
+	report, err := bus.PublishResult(ctx, testEvent, nil, eventbus.WithMatchTraceEventOpt(&trace))
+	if err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if matched, evaluated := counts(); matched != 1 || evaluated != 1 {
+		t.Error("expected the matcher to be evaluated once", matched, evaluated)
+	}
+	if len(trace) != 1 || !trace[0].Matched || trace[0].Matcher != "te*" {
+		t.Error("expected the trace to record the match", trace)
+	}
+	if len(report.Subscriptions) != 1 || !report.Subscriptions[0].Matched {
+		t.Error("expected the report to record the match", report.Subscriptions)
+	}
+}
+
+func TestBloomNameMatcher_InsertedNames_AllMatch(t *testing.T) {
+	names := make([]string, 1000)
+	for i := range names {
+		names[i] = "event." + strconv.Itoa(i)
+	}
+	m := eventbus.BloomNameMatcher(names, 0.01)
+
+	for _, name := range names {
+		if !m.Match(EventName(name), nil) {
+			t.Error("expected inserted name to match", name)
+		}
+	}
+}
+
+func TestBloomNameMatcher_OtherNames_MostlyDoNotMatch(t *testing.T) {
+	names := make([]string, 1000)
+	for i := range names {
+		names[i] = "event." + strconv.Itoa(i)
+	}
+	m := eventbus.BloomNameMatcher(names, 0.01)
+
+	falsePositives := 0
+	for i := 0; i < 1000; i++ {
+		if m.Match(EventName("other."+strconv.Itoa(i)), nil) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 50 {
+		t.Error("expected false positives near the configured rate", falsePositives)
+	}
+	if m.String() != "bloom[1000]" {
+		t.Error("expected String to include the name count", m.String())
+	}
+}
+
+func BenchmarkBloomNameMatcher(b *testing.B) {
+	names := make([]string, 100000)
+	for i := range names {
+		names[i] = "event." + strconv.Itoa(i)
+	}
+	m := eventbus.BloomNameMatcher(names, 0.01)
+	name := EventName("event.50000")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Match(name, nil)
+	}
+}
+
+func BenchmarkBloomNameMatcher_Map(b *testing.B) {
+	set := make(map[string]struct{}, 100000)
+	for i := 0; i < 100000; i++ {
+		set["event."+strconv.Itoa(i)] = struct{}{}
+	}
+	m := eventbus.PredicateMatcher(func(name eventbus.Stringer, _ interface{}) bool {
+		_, ok := set[name.String()]
+		return ok
+	})
+	name := EventName("event.50000")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Match(name, nil)
+	}
+}
+
+type otherName struct{ name string }
+
+func (n otherName) String() string { return n.name }
+
+func TestStringMatcher_MatchesNamesIgnoringCase(t *testing.T) {
+	m := eventbus.StringMatcher("Foo")
+
+	if !m.Match(EventName("foo"), nil) {
+		t.Error("expected foo to match")
+	}
+	if !m.Match(EventName("FOO"), nil) {
+		t.Error("expected FOO to match")
+	}
+}
+
+func TestStringMatcher_OtherNames_DoNotMatch(t *testing.T) {
+	m := eventbus.StringMatcher("bar")
+
+	if m.Match(EventName("foo"), nil) {
+		t.Error("expected foo to not match")
+	}
+	if m.Match(EventName("barn"), nil) {
+		t.Error("expected barn to not match")
+	}
+}
+
+func TestStringMatcher_DistinctStringerTypes_MatchBySameString(t *testing.T) {
+	m := eventbus.StringMatcher("foo")
+
+	if !m.Match(EventName("foo"), nil) {
+		t.Error("expected EventName to match")
+	}
+	if !m.Match(otherName{"foo"}, nil) {
+		t.Error("expected other Stringer with the same string to match")
+	}
+	if m.Match(otherName{"bar"}, nil) {
+		t.Error("expected other Stringer with a different string to not match")
+	}
+}
+
+func TestWildcardMatcher_NoWildcards_MatchesWholeNameOnly(t *testing.T) {
+	m := eventbus.WildcardMatcher("foo")
+
+	if !m.Match(EventName("foo"), nil) {
+		t.Error("expected foo to match")
+	}
+	if m.Match(EventName("foobar"), nil) {
+		t.Error("expected foobar to not match")
+	}
+	if m.Match(EventName("barfoo"), nil) {
+		t.Error("expected barfoo to not match")
+	}
+}
+
+func TestWildcardMatcher_Prefix_MatchesNamesWithPrefix(t *testing.T) {
+	m := eventbus.WildcardMatcher("foo*")
+
+	if !m.Match(EventName("foo"), nil) {
+		t.Error("expected foo to match")
+	}
+	if !m.Match(EventName("foobar"), nil) {
+		t.Error("expected foobar to match")
+	}
+	if m.Match(EventName("barfoo"), nil) {
+		t.Error("expected barfoo to not match")
+	}
+}
+
+func TestWildcardMatcher_Suffix_MatchesNamesWithSuffix(t *testing.T) {
+	m := eventbus.WildcardMatcher("*bar")
+
+	if !m.Match(EventName("foobar"), nil) {
+		t.Error("expected foobar to match")
+	}
+	if m.Match(EventName("barfoo"), nil) {
+		t.Error("expected barfoo to not match")
+	}
+}
+
+func TestWildcardMatcher_QuestionMark_MatchesSingleCharacter(t *testing.T) {
+	m := eventbus.WildcardMatcher("fo?")
+
+	if !m.Match(EventName("foo"), nil) {
+		t.Error("expected foo to match")
+	}
+	if m.Match(EventName("fo"), nil) {
+		t.Error("expected fo to not match")
+	}
+	if m.Match(EventName("fooo"), nil) {
+		t.Error("expected fooo to not match")
+	}
+}
+
+func TestWildcardMatcher_RegexMetacharacters_MatchLiterally(t *testing.T) {
+	m := eventbus.WildcardMatcher("order.created")
+
+	if !m.Match(EventName("order.created"), nil) {
+		t.Error("expected order.created to match")
+	}
+	if m.Match(EventName("orderXcreated"), nil) {
+		t.Error("expected orderXcreated to not match")
+	}
+}
+
+func TestAndMatcher_WildcardAndPredicate_MatchesOnlyWhenBothMatch(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var called []string
+	large := eventbus.PredicateMatcher(func(_ eventbus.Stringer, data interface{}) bool {
+		total, ok := data.(int)
+		return ok && total > 100
+	})
+	bus.When(eventbus.AndMatcher(eventbus.WildcardMatcher("order.*"), large)).
+		Do(func(_ context.Context, name eventbus.Stringer, _ interface{}) error {
+			called = append(called, name.String())
+			return nil
+		})
+
+	publish := func(name string, total int) {
+		if err := bus.Publish(ctx, EventName(name), total); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+	publish("order.small", 10)
+	publish("order.large", 1000)
+	publish("user.large", 1000)
+
+	if len(called) != 1 || called[0] != "order.large" {
+		t.Error("expected only events matching both matchers to be handled", called)
+	}
+}
+
+func TestAndMatcher_String_JoinsChildren(t *testing.T) {
+	m := eventbus.AndMatcher(eventbus.PrefixMatcher("order."), eventbus.SuffixMatcher(".created"))
+
+	if m.String() != "(order.* && *.created)" {
+		t.Error("expected children to be joined", m.String())
+	}
+}
+
+func TestAnd_AfterOr_AndsWithMostRecentMatcher(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var called []string
+	s := bus.On(EventName("user.created")).
+		Or(eventbus.PrefixMatcher("order.")).
+		And(eventbus.SuffixMatcher(".created"))
+	s.Do(func(_ context.Context, name eventbus.Stringer, _ interface{}) error {
+		called = append(called, name.String())
+		return nil
+	})
+
+	for _, name := range []string{"user.created", "order.created", "order.deleted", "user.deleted"} {
+		if err := bus.Publish(ctx, EventName(name), nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if strings.Join(called, ",") != "user.created,order.created" {
+		t.Error("expected user.created or order.*.created to match", called)
+	}
+}
+
+func TestNotMatcher_ExactMatcher_MatchesEveryOtherEvent(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var called []string
+	bus.When(eventbus.NotMatcher(eventbus.ExactMatcher(testEvent))).
+		Do(func(_ context.Context, name eventbus.Stringer, _ interface{}) error {
+			called = append(called, name.String())
+			return nil
+		})
+
+	for _, name := range []eventbus.Stringer{EventName("first"), testEvent, EventName("second")} {
+		if err := bus.Publish(ctx, name, nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if strings.Join(called, ",") != "first,second" {
+		t.Error("expected every event except the named one to match", called)
+	}
+}
+
+func TestNotMatcher_Wildcard_ExcludesNamespace(t *testing.T) {
+	m := eventbus.NotMatcher(eventbus.WildcardMatcher("internal.*"))
+
+	if m.Match(EventName("internal.cache.evicted"), nil) {
+		t.Error("expected internal event to not match")
+	}
+	if !m.Match(EventName("user.created"), nil) {
+		t.Error("expected other event to match")
+	}
+	if m.String() != `!^internal\..*$` {
+		t.Error("expected String to negate the inner matcher", m.String())
+	}
+}
+
+func TestOr_AndNotComposition_MatchesAndDescribesExpression(t *testing.T) {
+	m := eventbus.Or(
+		eventbus.And(eventbus.PrefixMatcher("a"), eventbus.Not(eventbus.SuffixMatcher("z"))),
+		eventbus.ExactMatcher(testEvent),
+	)
+
+	for name, want := range map[eventbus.Stringer]bool{
+		EventName("abc"):  true,
+		EventName("abz"):  false,
+		EventName("bcd"):  false,
+		testEvent:         true,
+		otherName{"test"}: false,
+	} {
+		if got := m.Match(name, nil); got != want {
+			t.Error("expected match result", name, want, got)
+		}
+	}
+	if m.String() != "((a* && !*z) || predicate)" {
+		t.Error("expected String to describe the expression", m.String())
+	}
+}
+
+func TestOr_NoMatchers_MatchesNothing(t *testing.T) {
+	if eventbus.Or().Match(testEvent, nil) {
+		t.Error("expected an empty Or to match nothing")
+	}
+	if !eventbus.And().Match(testEvent, nil) {
+		t.Error("expected an empty And to match everything")
+	}
+}
+
+func TestSizeMatcher_BytePayloads_RoutedBySize(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var small, large int
+	bus.When(eventbus.SizeMatcher(0, 1023)).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		small++
+		return nil
+	})
+	bus.When(eventbus.SizeMatcher(1024, 1<<20)).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		large++
+		return nil
+	})
+
+	for _, data := range [][]byte{make([]byte, 10), make([]byte, 4096), make([]byte, 1023), make([]byte, 1024)} {
+		if err := bus.Publish(ctx, testEvent, data); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if small != 2 || large != 2 {
+		t.Error("expected payloads to be routed by size", small, large)
+	}
+}
+
+func TestSizeMatcher_OtherPayloads_MatchWhenSizable(t *testing.T) {
+	m := eventbus.SizeMatcher(1, 3)
+
+	if !m.Match(testEvent, "abc") {
+		t.Error("expected string within range to match")
+	}
+	if !m.Match(testEvent, strings.NewReader("ab")) {
+		t.Error("expected data with Len within range to match")
+	}
+	if m.Match(testEvent, "abcd") {
+		t.Error("expected string outside range to not match")
+	}
+	if m.Match(testEvent, 2) {
+		t.Error("expected non-sizable data to not match")
+	}
+	if m.Match(testEvent, nil) {
+		t.Error("expected nil data to not match")
+	}
+	if m.String() != "size[1,3]" {
+		t.Error("expected String to render the range", m.String())
+	}
+}
+
+func TestHasFieldMatcher_MapPayload_MatchesWhenPathExists(t *testing.T) {
+	m := eventbus.HasFieldMatcher("user.address.city")
+
+	if !m.Match(testEvent, map[string]interface{}{
+		"user": map[string]interface{}{"address": map[string]interface{}{"city": nil}},
+	}) {
+		t.Error("expected an existing path with a nil value to match")
+	}
+	if m.Match(testEvent, map[string]interface{}{
+		"user": map[string]interface{}{"address": map[string]interface{}{"street": "main"}},
+	}) {
+		t.Error("expected a missing path to not match")
+	}
+	if m.Match(testEvent, map[string]interface{}{"user": map[string]interface{}{"address": nil}}) {
+		t.Error("expected a nil intermediate to not match")
+	}
+	if m.Match(testEvent, nil) {
+		t.Error("expected nil data to not match")
+	}
+	if m.String() != "has(user.address.city)" {
+		t.Error("expected String to name the path", m.String())
+	}
+}
+
+func TestHasFieldMatcher_StructPayload_MatchesFieldOrJSONName(t *testing.T) {
+	type address struct {
+		City string `json:"city"`
+	}
+	type user struct {
+		Address *address
+		secret  string
+	}
+
+	if !eventbus.HasFieldMatcher("Address.city").Match(testEvent, user{Address: &address{}}) {
+		t.Error("expected field and JSON names to match")
+	}
+	if !eventbus.HasFieldMatcher("Address.City").Match(testEvent, &user{Address: &address{}}) {
+		t.Error("expected a pointer payload to match")
+	}
+	if eventbus.HasFieldMatcher("Address.city").Match(testEvent, user{}) {
+		t.Error("expected a nil pointer intermediate to not match")
+	}
+	if eventbus.HasFieldMatcher("secret").Match(testEvent, user{secret: "x"}) {
+		t.Error("expected unexported fields to not match")
+	}
+}
+
+type order struct {
+	Total float64
+}
+
+func TestDataMatcher_StructField_MatchesPredicate(t *testing.T) {
+	m := eventbus.DataMatcher(func(o order) bool { return o.Total > 100 })
+
+	if !m.Match(testEvent, order{Total: 150}) {
+		t.Error("expected an order over 100 to match")
+	}
+	if m.Match(testEvent, order{Total: 50}) {
+		t.Error("expected an order under 100 to not match")
+	}
+	if m.String() != "data(eventbus_test.order)" {
+		t.Error("expected String to name the data type", m.String())
+	}
+}
+
+func TestDataMatcher_MismatchedType_DoesNotMatch(t *testing.T) {
+	m := eventbus.DataMatcher(func(o order) bool { return true })
+
+	if m.Match(testEvent, &order{Total: 150}) {
+		t.Error("expected a pointer to not match a value type")
+	}
+	if m.Match(testEvent, "order") {
+		t.Error("expected a string to not match")
+	}
+	if m.Match(testEvent, nil) {
+		t.Error("expected nil data to not match")
+	}
+}
+
+func TestDataMatcher_WithWhenAndOr_RunsHandlerForMatchingData(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var totals []float64
+	bus.When(eventbus.DataMatcher(func(o order) bool { return o.Total > 100 })).
+		Or(eventbus.DataMatcher(func(n int) bool { return n < 0 })).
+		Do(func(_ context.Context, _ eventbus.Stringer, data interface{}) error {
+			if o, ok := data.(order); ok {
+				totals = append(totals, o.Total)
+			} else {
+				totals = append(totals, float64(data.(int)))
+			}
+			return nil
+		})
+
+	for _, data := range []interface{}{order{Total: 50}, order{Total: 150}, 1, -1} {
+		if err := bus.Publish(ctx, testEvent, data); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if len(totals) != 2 || totals[0] != 150 || totals[1] != -1 {
+		t.Error("expected only matching data to be handled", totals)
+	}
+}
+
+type (
+	tenantKey     struct{}
+	tenantMatcher string
+)
+
+func (m tenantMatcher) Match(eventbus.Stringer, interface{}) bool {
+	return false
+}
+
+func (m tenantMatcher) MatchContext(ctx context.Context, _ eventbus.Stringer, _ interface{}) bool {
+	return ctx.Value(tenantKey{}) == string(m)
+}
+
+func (m tenantMatcher) String() string {
+	return "tenant(" + string(m) + ")"
+}
+
+func TestContextMatcher_TenantInContext_MatchesOnlyThatTenant(t *testing.T) {
+	bus := eventbus.New()
+	var tenants []string
+	bus.When(tenantMatcher("a")).Do(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) error {
+		tenants = append(tenants, ctx.Value(tenantKey{}).(string))
+		return nil
+	})
+
+	for _, tenant := range []string{"a", "b", "a"} {
+		ctx := context.WithValue(context.Background(), tenantKey{}, tenant)
+		if err := bus.Publish(ctx, testEvent, nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+	if err := bus.Publish(context.Background(), testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if strings.Join(tenants, ",") != "a,a" {
+		t.Error("expected only the events of tenant a to be handled", tenants)
+	}
+}
+
+func TestContextMatcher_WithinAnd_GetsPublishContext(t *testing.T) {
+	bus := eventbus.New()
+	called := 0
+	bus.When(eventbus.And(eventbus.PrefixMatcher("te"), eventbus.Not(tenantMatcher("b")))).
+		Do(func(context.Context, eventbus.Stringer, interface{}) error {
+			called++
+			return nil
+		})
+
+	for _, tenant := range []string{"a", "b"} {
+		ctx := context.WithValue(context.Background(), tenantKey{}, tenant)
+		if err := bus.Publish(ctx, testEvent, nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if called != 1 {
+		t.Error("expected only the event of tenant a to be handled", called)
+	}
+}
+
+func TestContextMatcher_WithinInstrumentedMatcher_GetsPublishContext(t *testing.T) {
+	bus := eventbus.New()
+	m, counts := eventbus.InstrumentedMatcher(tenantMatcher("a"))
+	called := 0
+	bus.When(m).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		called++
+		return nil
+	})
+
+	for _, tenant := range []string{"a", "b"} {
+		ctx := context.WithValue(context.Background(), tenantKey{}, tenant)
+		if err := bus.Publish(ctx, testEvent, nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if called != 1 {
+		t.Error("expected only the event of tenant a to be handled", called)
+	}
+	if matched, evaluated := counts(); matched != 1 || evaluated != 2 {
+		t.Error("expected 1 match out of 2 evaluations", matched, evaluated)
+	}
+}
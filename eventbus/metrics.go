@@ -0,0 +1,48 @@
+package eventbus
+
+import (
+	"errors"
+	"time"
+)
+
+type (
+	// Metrics receives measurements of the bus, for example to export them to
+	// Prometheus. Its methods are called from publishing goroutines, so they
+	// must be safe for concurrent use and should return quickly.
+	Metrics interface {
+		// IncPublished counts an event dispatched by a publish.
+		IncPublished(name string)
+		// ObserveHandlerDuration measures how long a subscription's handler
+		// took to handle an event, including its retries.
+		ObserveHandlerDuration(name, subID string, d time.Duration)
+		// IncError counts a handler or observer that failed to handle an event,
+		// including by timing out.
+		IncError(name string)
+		// IncTimeout counts a handler, observer or publish that timed out.
+		IncTimeout(name string)
+		// SetQueueDepth reports the number of publishes waiting for a worker,
+		// when the bus was created with WithQueueBusOpt.
+		SetQueueDepth(depth int)
+	}
+
+	noopMetrics struct{}
+)
+
+func (noopMetrics) IncPublished(string)                                  {}
+func (noopMetrics) ObserveHandlerDuration(string, string, time.Duration) {}
+func (noopMetrics) IncError(string)                                      {}
+func (noopMetrics) IncTimeout(string)                                    {}
+func (noopMetrics) SetQueueDepth(int)                                    {}
+
+// countError counts the error of a handler or observer for the event, and
+// whether it was a timeout. A nil error isn't counted.
+func (b *bus) countError(e Event, err error) {
+	if err == nil {
+		return
+	}
+
+	b.metrics.IncError(e.Name.String())
+	if errors.Is(err, ErrHandlerTimeout) {
+		b.metrics.IncTimeout(e.Name.String())
+	}
+}
@@ -0,0 +1,139 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+)
+
+type fakeMetrics struct {
+	mu        sync.Mutex
+	published map[string]int
+	errors    map[string]int
+	timeouts  map[string]int
+	durations map[string][]time.Duration
+	depths    []int
+}
+
+func newFakeMetrics() *fakeMetrics {
+	return &fakeMetrics{
+		published: make(map[string]int),
+		errors:    make(map[string]int),
+		timeouts:  make(map[string]int),
+		durations: make(map[string][]time.Duration),
+	}
+}
+
+func (m *fakeMetrics) IncPublished(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.published[name]++
+}
+
+func (m *fakeMetrics) ObserveHandlerDuration(name, subID string, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.durations[subID] = append(m.durations[subID], d)
+}
+
+func (m *fakeMetrics) IncError(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors[name]++
+}
+
+func (m *fakeMetrics) IncTimeout(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.timeouts[name]++
+}
+
+func (m *fakeMetrics) SetQueueDepth(depth int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.depths = append(m.depths, depth)
+}
+
+func TestPublish_WithMetrics_CountsPublishesAndHandlerDurations(t *testing.T) {
+	ctx := context.Background()
+	metrics := newFakeMetrics()
+	bus := eventbus.New(eventbus.WithMetricsBusOpt(metrics))
+	s := bus.On(testEvent)
+	s.Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	})
+
+	for i := 0; i < 3; i++ {
+		if err := bus.Publish(ctx, testEvent, nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if metrics.published["test"] != 3 {
+		t.Error("expected every publish to be counted", metrics.published)
+	}
+	if d := metrics.durations[s.String()]; len(d) != 3 || d[0] < 5*time.Millisecond {
+		t.Error("expected the handler durations", d)
+	}
+	if len(metrics.errors) != 0 || len(metrics.timeouts) != 0 {
+		t.Error("expected no errors", metrics.errors, metrics.timeouts)
+	}
+}
+
+func TestPublish_WithMetricsAndFailures_CountsErrors(t *testing.T) {
+	ctx := context.Background()
+	metrics := newFakeMetrics()
+	bus := eventbus.New(eventbus.WithMetricsBusOpt(metrics), eventbus.WithContinueOnErrorBusOpt())
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		return errors.New("failed")
+	})
+	bus.AddErrorObserver(errorObserverFunc(func(context.Context, eventbus.Stringer, interface{}) error {
+		return errors.New("failed")
+	}))
+
+	if err := bus.Publish(ctx, testEvent, nil); err == nil {
+		t.Error("expected an error")
+	}
+	bus.Flush(ctx)
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if metrics.errors["test"] != 2 {
+		t.Error("expected the handler and observer errors to be counted", metrics.errors)
+	}
+	if len(metrics.timeouts) != 0 {
+		t.Error("expected no timeouts", metrics.timeouts)
+	}
+}
+
+func TestPublish_WithMetricsAndTimeouts_CountsTimeouts(t *testing.T) {
+	ctx := context.Background()
+	metrics := newFakeMetrics()
+	bus := eventbus.New(eventbus.WithMetricsBusOpt(metrics))
+	bus.On(testEvent).Do(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithHandlerTimeoutEventOpt(time.Millisecond)); !errors.Is(err, eventbus.ErrHandlerTimeout) {
+		t.Error("expected handler timeout", err)
+	}
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithPublishTimeoutEventOpt(time.Millisecond)); !errors.Is(err, eventbus.ErrPublishTimeout) {
+		t.Error("expected publish timeout", err)
+	}
+	bus.Flush(ctx)
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if metrics.timeouts["test"] != 2 {
+		t.Error("expected the handler and publish timeouts to be counted", metrics.timeouts)
+	}
+	if metrics.errors["test"] < 1 {
+		t.Error("expected the handler timeout to be counted as an error", metrics.errors)
+	}
+}
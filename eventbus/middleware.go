@@ -0,0 +1,24 @@
+package eventbus
+
+import "context"
+
+type (
+	// PublishFunc dispatches a published event.
+	PublishFunc func(ctx context.Context, e Event) error
+
+	// Middleware wraps the dispatch of every publish, for cross-cutting
+	// concerns like auth, logging or validation. It can reject a publish by
+	// returning an error without calling next, change the event it passes to
+	// next, or observe the result and timing of next.
+	Middleware func(next PublishFunc) PublishFunc
+)
+
+// chain returns the dispatch of the bus wrapped in its middlewares, the first
+// registered being the outermost.
+func (b *bus) chain() PublishFunc {
+	next := PublishFunc(b.publish)
+	for i := len(b.middlewares) - 1; i >= 0; i-- {
+		next = b.middlewares[i](next)
+	}
+	return next
+}
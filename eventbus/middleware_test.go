@@ -0,0 +1,83 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+)
+
+func TestPublish_WithMiddlewares_WrapInRegistrationOrder(t *testing.T) {
+	ctx := context.Background()
+	var calls []string
+	mw := func(name string) eventbus.Middleware {
+		return func(next eventbus.PublishFunc) eventbus.PublishFunc {
+			return func(ctx context.Context, e eventbus.Event) error {
+				calls = append(calls, name+" before")
+				err := next(ctx, e)
+				calls = append(calls, name+" after")
+				return err
+			}
+		}
+	}
+	bus := eventbus.New(eventbus.WithMiddlewareBusOpt(mw("first")), eventbus.WithMiddlewareBusOpt(mw("second")))
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		calls = append(calls, "handler")
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	expected := "first before,second before,handler,second after,first after"
+	if got := strings.Join(calls, ","); got != expected {
+		t.Error("expected middlewares to wrap in registration order", got)
+	}
+}
+
+func TestPublish_WithRejectingMiddleware_DoesNotCallHandler(t *testing.T) {
+	ctx := context.Background()
+	errUnauthorized := errors.New("unauthorized")
+	bus := eventbus.New(eventbus.WithMiddlewareBusOpt(func(next eventbus.PublishFunc) eventbus.PublishFunc {
+		return func(context.Context, eventbus.Event) error {
+			return errUnauthorized
+		}
+	}))
+	called := false
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		called = true
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, errUnauthorized) {
+		t.Error("expected the middleware error", err)
+	}
+	if called {
+		t.Error("expected the handler to not be called")
+	}
+}
+
+func TestPublish_WithMutatingMiddleware_HandlerSeesChangedEvent(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithMiddlewareBusOpt(func(next eventbus.PublishFunc) eventbus.PublishFunc {
+		return func(ctx context.Context, e eventbus.Event) error {
+			e.Data = strings.ToUpper(e.Data.(string))
+			return next(ctx, e)
+		}
+	}))
+	var got interface{}
+	bus.On(testEvent).Do(func(_ context.Context, _ eventbus.Stringer, data interface{}) error {
+		got = data
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, "data"); err != nil {
+		t.Error("expected no error", err)
+	}
+	if got != "DATA" {
+		t.Error("expected the handler to see the changed data", got)
+	}
+}
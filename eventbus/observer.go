@@ -9,11 +9,49 @@ type (
 	observer interface {
 		Observe(ctx context.Context, name Stringer, data interface{})
 	}
-	observerWithOptions struct {
+	// ErrorObserver is an observer that can fail. Its errors are reported like
+	// observer timeouts and panics.
+	ErrorObserver interface {
+		Observe(ctx context.Context, name Stringer, data interface{}) error
+	}
+	// observerAdapter adapts an observer to an ErrorObserver that never fails.
+	observerAdapter struct {
 		observer
+	}
+	observerWithOptions struct {
+		ErrorObserver
+		id   string
+		seq  uint64
 		opts observerOptions
 	}
 	observerOptions struct {
-		timeout time.Duration
+		timeout  time.Duration
+		critical bool
+		matchers []Matcher
 	}
 )
+
+func (a observerAdapter) Observe(ctx context.Context, name Stringer, data interface{}) error {
+	a.observer.Observe(ctx, name, data)
+	return nil
+}
+
+// match reports whether the observer wants the event: when it has matchers, at
+// least one of them must match. Events published with WithOnlyObserversEventOpt
+// are only for the listed observers.
+func (o observerWithOptions) match(e Event) bool {
+	if e.onlyObservers != nil {
+		if _, ok := e.onlyObservers[o.id]; !ok {
+			return false
+		}
+	}
+	if len(o.opts.matchers) == 0 {
+		return true
+	}
+	for _, m := range o.opts.matchers {
+		if m.Match(e.Name, e.Data) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,14 @@
 package eventbus
 
-import "time"
+import (
+	"context"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/exp/slog"
+	"golang.org/x/time/rate"
+)
 
 type (
 	eventOpt    func(*Event)
@@ -23,6 +31,319 @@ var (
 			b.continueOnError = true
 		}
 	}
+	// Acquires concurrency slots for observers n at a time rather than one by
+	// one. The batch size is capped at the bus concurrency.
+	WithObserverBatchSizeBusOpt = func(n int64) busOpt {
+		return func(b *bus) {
+			if n < 1 {
+				n = 1
+			}
+			b.observerBatch = n
+		}
+	}
+	// Removes the concurrency limit, so observers are launched without
+	// acquiring a semaphore.
+	WithUnlimitedConcurrencyBusOpt = func() busOpt {
+		return func(b *bus) {
+			b.concurrency = 0
+		}
+	}
+	// Calls hook before each event is dispatched. The returned function, if
+	// not nil, is called with the publish error once the publish completes.
+	WithPublishHookBusOpt = func(hook func(e Event) func(err error)) busOpt {
+		return func(b *bus) {
+			b.publishHook = hook
+		}
+	}
+	// Shares a single dispatch between concurrent publishes whose events have
+	// the same key; all of them return the result of that dispatch. Events
+	// with an empty key are dispatched as usual.
+	WithSingleFlightBusOpt = func(key func(Event) string) busOpt {
+		return func(b *bus) {
+			b.singleFlightKey = key
+		}
+	}
+	// Reports errors that can't be returned from Publish, such as diagnostics
+	// and failures that happen after the publish has returned, along with
+	// every panic recovered from a handler or observer.
+	WithErrorHandlerBusOpt = func(fn func(ctx context.Context, err error)) busOpt {
+		return func(b *bus) {
+			b.errorHandler = fn
+		}
+	}
+	// Captures the stack of a handler that times out and reports it to the
+	// error handler as a *StackError.
+	WithCaptureStackOnTimeoutBusOpt = func() busOpt {
+		return func(b *bus) {
+			b.captureStack = true
+		}
+	}
+	// Makes On match event names case-insensitively, comparing them as
+	// strings. Subscriptions are then keyed by the case-folded name, so
+	// differently cased names share the same registry entry and are still
+	// looked up by name when publishing.
+	WithCaseInsensitiveNamesBusOpt = func() busOpt {
+		return func(b *bus) {
+			b.caseInsensitive = true
+		}
+	}
+	// Records every published event to the sink before it is dispatched.
+	WithAuditSinkBusOpt = func(sink AuditSink) busOpt {
+		return func(b *bus) {
+			b.auditSink = sink
+		}
+	}
+	// Records events to the audit sink after they are dispatched instead of
+	// before.
+	WithAuditAfterDispatchBusOpt = func() busOpt {
+		return func(b *bus) {
+			b.auditAfter = true
+		}
+	}
+	// Logs a warning when an event matches a subscription that has no
+	// handlers, which usually means Do was never called on it.
+	WithWarnEmptySubscriptionsBusOpt = func() busOpt {
+		return func(b *bus) {
+			b.warnEmpty = true
+		}
+	}
+	WithClockBusOpt = func(c Clock) busOpt {
+		return func(b *bus) {
+			b.clock = c
+		}
+	}
+	// Rejects publishes whose data is nil with ErrNilData. A nil pointer
+	// stored in the data interface is not rejected.
+	WithRejectNilDataBusOpt = func() busOpt {
+		return func(b *bus) {
+			b.rejectNilData = true
+		}
+	}
+	// Limits the publish context values carried into observers, and into work
+	// that outlives the publish such as async publishes, to the listed keys.
+	// By default all values are carried.
+	WithContextKeysBusOpt = func(keys ...interface{}) busOpt {
+		return func(b *bus) {
+			b.contextKeys = append([]interface{}{}, keys...)
+		}
+	}
+	// Calls capture with the full event, including its data, whenever a
+	// handler returns an error, for example to dump the payload for a
+	// postmortem.
+	WithCaptureOnErrorBusOpt = func(capture func(Event)) busOpt {
+		return func(b *bus) {
+			b.captureOnError = capture
+		}
+	}
+	// Runs observers added with WithCriticalObserverOpt synchronously, in the
+	// order they were added, before any other observer or subscription. A
+	// critical observer that fails aborts the publish with its error.
+	WithCriticalObserversFirstBusOpt = func() busOpt {
+		return func(b *bus) {
+			b.criticalFirst = true
+		}
+	}
+	// Publishes events that match no subscriptions and reach no observers
+	// again under name, with the original Event as the data, so they can be
+	// handled as dead letters. Unhandled events are never redirected again.
+	WithUnhandledEventBusOpt = func(name Stringer) busOpt {
+		return func(b *bus) {
+			b.unhandledName = name
+		}
+	}
+	// Notifies observers only of events that match at least one of the
+	// matchers. Observers without matchers of their own are notified of every
+	// event that passes this filter.
+	WithObserverMatchersBusOpt = func(matchers ...Matcher) busOpt {
+		return func(b *bus) {
+			b.observerFilter = append([]Matcher{}, matchers...)
+		}
+	}
+	// Writes the bus trace records, such as published events and failed
+	// handlers, to logger instead of the default slog logger.
+	WithLoggerBusOpt = func(logger *slog.Logger) busOpt {
+		return func(b *bus) {
+			b.logger = logger
+		}
+	}
+	// Runs the matching subscriptions of each publish concurrently, at most
+	// the max concurrency at a time, instead of one after the other. Each
+	// subscription still runs its own handlers in order. Without
+	// continueOnError, the first error cancels the context of the others.
+	WithConcurrentSubscribersBusOpt = func() busOpt {
+		return func(b *bus) {
+			b.concurrentSubscribers = true
+		}
+	}
+	// Runs everything on the publishing goroutine, one at a time: observers in
+	// the order they were added, then subscriptions in priority and
+	// registration order. Handler and publish timeouts are ignored, async
+	// events are published synchronously, and the concurrency options have no
+	// effect, in whatever order they are given. Meant for tests that need
+	// reproducible ordering without sleeps or flushes.
+	WithDeterministicBusOpt = func() busOpt {
+		return func(b *bus) {
+			b.deterministic = true
+		}
+	}
+	// Writes every published event to w as a line of JSON before it is
+	// dispatched, so the events can be replayed with Replay.
+	WithRecorderBusOpt = func(w io.Writer) busOpt {
+		return func(b *bus) {
+			b.recorder = w
+		}
+	}
+	// Publishes EventBusStarted, with nil data, once, right before the first
+	// event published on the bus, so that initialization handlers can
+	// subscribe to it. Its errors are reported to the error handler.
+	WithStartupEventBusOpt = func() busOpt {
+		return func(b *bus) {
+			b.startup = true
+		}
+	}
+	// Calls fn with the event and the error whenever a subscription fails to
+	// handle an event, after any retries; with continueOnError, once for each
+	// failing subscription. The publish waits for fn up to the dead-letter
+	// timeout, 5 seconds by default.
+	WithDeadLetterBusOpt = func(fn func(ctx context.Context, e Event, err error)) busOpt {
+		return func(b *bus) {
+			b.deadLetterFn = fn
+		}
+	}
+	// Sets how long a publish waits for the dead-letter function. A
+	// non-positive timeout waits until it returns.
+	WithDeadLetterTimeoutBusOpt = func(d time.Duration) busOpt {
+		return func(b *bus) {
+			b.deadLetterTimeout = d
+		}
+	}
+	// Runs async events, published with WithAsyncEventOpt, at most the max
+	// concurrency at a time, taking turns between event names so that a flood
+	// of one name can't starve the others. Events of the same name are still
+	// started in the order they were published. With WithQueueBusOpt, the
+	// queue workers take turns between event names instead, and their number
+	// limits the concurrency.
+	WithFairSchedulingBusOpt = func() busOpt {
+		return func(b *bus) {
+			b.fair = true
+		}
+	}
+	// Starts a span named after the event for each publish, with a child span
+	// for each handler and observer it runs, and records their errors on
+	// them. Handlers and observers get the context of their span.
+	WithTracerBusOpt = func(tracer trace.Tracer) busOpt {
+		return func(b *bus) {
+			b.tracer = tracer
+		}
+	}
+	// Reports publishes, handler durations, errors and timeouts to m. A nil m
+	// reports nothing.
+	WithMetricsBusOpt = func(m Metrics) busOpt {
+		return func(b *bus) {
+			if m == nil {
+				m = noopMetrics{}
+			}
+			b.metrics = m
+		}
+	}
+	// Queues publishes for a pool of workers instead of dispatching them on the
+	// calling goroutine, so Publish returns once the event is queued. Publish
+	// blocks while size events are queued, until ctx is done. Close drains the
+	// events already queued. A size below 1 queues a single event, and a
+	// non-positive workers starts a single worker. Handlers publishing to a full queue can deadlock the workers, so size
+	// must allow for them. With WithFairSchedulingBusOpt, the workers take
+	// turns between event names rather than running the events in order.
+	WithQueueBusOpt = func(size int, workers int) busOpt {
+		return func(b *bus) {
+			if workers <= 0 {
+				workers = 1
+			}
+			b.queueSize = size
+			b.queueWorkers = workers
+		}
+	}
+	// Tracks the fraction of publishes of each event name that return an error
+	// over a sliding window of a minute, for ErrorRate.
+	WithErrorRateTrackingBusOpt = func() busOpt {
+		return func(b *bus) {
+			b.errorRates = newErrorRates()
+		}
+	}
+	// Caps the time every handler and observer may take to handle an event.
+	// WithHandlerTimeoutEventOpt can only tighten it: the shorter of the two
+	// applies.
+	WithDefaultHandlerTimeoutBusOpt = func(d time.Duration) busOpt {
+		return func(b *bus) {
+			b.defaultHandlerTimeout = d
+		}
+	}
+	// Wraps the dispatch of every publish in the middleware. Middlewares wrap
+	// in the order they are added, so the first one added runs first.
+	WithMiddlewareBusOpt = func(mw Middleware) busOpt {
+		return func(b *bus) {
+			b.middlewares = append(b.middlewares, mw)
+		}
+	}
+	// Calls fn with the value and stack trace of every panic recovered from a
+	// handler or observer, for custom reporting. The panic still fails the
+	// handler or observer with a *PanicError.
+	WithPanicHandlerBusOpt = func(fn func(recovered interface{}, stack []byte)) busOpt {
+		return func(b *bus) {
+			b.panicHandler = fn
+		}
+	}
+	// Cancels the context of the publishes in flight, and so of their handlers
+	// and observers, when the bus is closed. context.Cause of the canceled
+	// context returns ErrBusClosed.
+	WithCancelOnCloseBusOpt = func() busOpt {
+		return func(b *bus) {
+			b.cancelOnClose = true
+		}
+	}
+	// Limits publishes of the event name to limit per second, with bursts of
+	// up to burst. Publishes over the limit fail with ErrRateLimited, unless
+	// the bus was created with WithRateLimitWaitBusOpt. Other names aren't
+	// limited.
+	WithRateLimitBusOpt = func(name Stringer, limit rate.Limit, burst int) busOpt {
+		return func(b *bus) {
+			if b.rateLimiters == nil {
+				b.rateLimiters = make(map[string]*rate.Limiter)
+			}
+			b.rateLimiters[name.String()] = rate.NewLimiter(limit, burst)
+		}
+	}
+	// Makes publishes over a rate limit wait for the limiter instead of
+	// failing. They fail with ErrRateLimited if the publish context is done,
+	// or the publish timeout elapses, before the limiter allows them.
+	WithRateLimitWaitBusOpt = func() busOpt {
+		return func(b *bus) {
+			b.rateLimitWait = true
+		}
+	}
+	// Drops a publish if an event with the same key, as returned by keyFn, was
+	// published within window before it, failing it with ErrDuplicate. Events
+	// whose key is empty are never dropped.
+	WithDedupeBusOpt = func(window time.Duration, keyFn func(Event) string) busOpt {
+		return func(b *bus) {
+			b.deduper = newDeduper(window, keyFn)
+		}
+	}
+	// Makes publishes dropped as duplicates return no error instead of
+	// ErrDuplicate.
+	WithSilentDedupeBusOpt = func() busOpt {
+		return func(b *bus) {
+			b.dedupeSilent = true
+		}
+	}
+	// Makes Close wait up to d for the events queued with WithQueueBusOpt to
+	// be handled. Once d elapses, the events no worker has started are sent to
+	// the dead-letter function with ErrBusClosed instead, and Close returns. A
+	// non-positive d doesn't wait, leaving the workers to drain the queue.
+	WithCloseDrainTimeoutBusOpt = func(d time.Duration) busOpt {
+		return func(b *bus) {
+			b.closeDrainTimeout = d
+		}
+	}
 )
 
 // Event options
@@ -37,6 +358,67 @@ var (
 			e.publishTimeout = d
 		}
 	}
+	// Runs the subscription handlers on the publishing goroutine without
+	// spawning timeout goroutines. Handler and publish timeouts are ignored.
+	WithInlineHandlersEventOpt = func() eventOpt {
+		return func(e *Event) {
+			e.inline = true
+		}
+	}
+	// Publishes the event on a new goroutine and returns immediately; Flush
+	// waits for it to finish. Since Publish can't return the event's errors,
+	// they are reported to the bus error handler instead.
+	WithAsyncEventOpt = func() eventOpt {
+		return func(e *Event) {
+			e.async = true
+		}
+	}
+	// Sets the timestamp of the event instead of the current time, for example
+	// to keep the original time of replayed or imported events. A zero time is
+	// ignored.
+	WithTimestampEventOpt = func(t time.Time) eventOpt {
+		return func(e *Event) {
+			if !t.IsZero() {
+				e.Timestamp = t.UTC()
+			}
+		}
+	}
+	// Adds a header to the event, replacing any header with the same key.
+	WithHeaderEventOpt = func(key, value string) eventOpt {
+		return func(e *Event) {
+			headers := make(map[string]string, len(e.Headers)+1)
+			for k, v := range e.Headers {
+				headers[k] = v
+			}
+			headers[key] = value
+			e.Headers = headers
+		}
+	}
+	// Publishes the event to subscriptions only; no observer is notified.
+	WithoutObserversEventOpt = func() eventOpt {
+		return func(e *Event) {
+			e.noObservers = true
+		}
+	}
+	// Notifies only the observers with the listed IDs, if they want the event.
+	// Without IDs, no observer is notified.
+	WithOnlyObserversEventOpt = func(ids ...string) eventOpt {
+		return func(e *Event) {
+			e.onlyObservers = make(map[string]struct{}, len(ids))
+			for _, id := range ids {
+				e.onlyObservers[id] = struct{}{}
+			}
+		}
+	}
+	// Records the match decision of every subscription into trace as the event
+	// is dispatched to them, to help debug why a handler did or didn't run.
+	// The trace stays empty if the event isn't dispatched, for example when a
+	// critical observer fails.
+	WithMatchTraceEventOpt = func(trace *MatchTrace) eventOpt {
+		return func(e *Event) {
+			e.trace = trace
+		}
+	}
 )
 
 // Observer options
@@ -46,4 +428,19 @@ var (
 			o.timeout = d
 		}
 	}
+	// Marks the observer as critical. Has no effect unless the bus was created
+	// with WithCriticalObserversFirstBusOpt.
+	WithCriticalObserverOpt = func() observerOpt {
+		return func(o *observerOptions) {
+			o.critical = true
+		}
+	}
+	// Notifies the observer only of events that match at least one of the
+	// matchers, like a subscription. Events that don't match never spawn a
+	// goroutine for the observer.
+	WithMatcherObserverOpt = func(matchers ...Matcher) observerOpt {
+		return func(o *observerOptions) {
+			o.matchers = append(o.matchers, matchers...)
+		}
+	}
 )
@@ -0,0 +1,163 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	// publishQueue is a bounded queue of publishes drained by a fixed pool of
+	// workers, taking turns between the keys the publishes were queued under.
+	// Once closed it rejects new publishes, and its workers exit after running
+	// the ones already queued, or dropping them once told to.
+	publishQueue struct {
+		// slots holds a token for each queued publish, so that pushes block
+		// while the queue is full.
+		slots chan struct{}
+		// done is closed once the workers have exited.
+		done     chan struct{}
+		dropping atomic.Bool
+
+		mu sync.Mutex
+		// ready is signaled when a publish is queued, and broadcast when the
+		// workers may have to exit.
+		ready   *sync.Cond
+		pending turns[queuedPublish]
+		closed  bool
+		senders int
+	}
+
+	// queuedPublish is a queued publish, along with what to do instead if it
+	// is dropped.
+	queuedPublish struct {
+		run  func()
+		drop func()
+	}
+)
+
+// newPublishQueue returns a queue holding up to size publishes, at least one,
+// and starts workers to run them.
+func newPublishQueue(size, workers int) *publishQueue {
+	if size < 1 {
+		size = 1
+	}
+
+	q := &publishQueue{slots: make(chan struct{}, size), done: make(chan struct{})}
+	q.ready = sync.NewCond(&q.mu)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			q.work()
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(q.done)
+	}()
+	return q
+}
+
+// work runs, or drops, the queued publishes until the queue is closed and
+// empty.
+func (q *publishQueue) work() {
+	for {
+		p, ok := q.next()
+		if !ok {
+			return
+		}
+		if q.dropping.Load() {
+			p.drop()
+		} else {
+			p.run()
+		}
+	}
+}
+
+// next waits for a queued publish and removes it from the queue. It returns
+// false once the queue is closed and no publishes are left or being queued.
+func (q *publishQueue) next() (queuedPublish, bool) {
+	q.mu.Lock()
+	for q.pending.empty() {
+		if q.closed && q.senders == 0 {
+			q.mu.Unlock()
+			return queuedPublish{}, false
+		}
+		q.ready.Wait()
+	}
+	p, _ := q.pending.next()
+	q.mu.Unlock()
+
+	<-q.slots
+	return p, true
+}
+
+// push queues run under key, blocking while the queue is full. If the queue
+// is dropped before a worker gets to it, drop is called instead. It returns
+// ErrBusClosed if the queue is closed, or the error of ctx if it is done
+// before run is queued.
+func (q *publishQueue) push(ctx context.Context, key string, run, drop func()) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return ErrBusClosed
+	}
+	q.senders++
+	q.mu.Unlock()
+
+	// The workers of a closed queue exit once the last sender is done.
+	defer func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.senders--
+		if q.closed && q.senders == 0 {
+			q.ready.Broadcast()
+		}
+	}()
+
+	select {
+	case q.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending.push(key, queuedPublish{run: run, drop: drop})
+	q.ready.Signal()
+	return nil
+}
+
+// close stops the queue from accepting publishes. The workers keep running
+// until the publishes already queued are done.
+func (q *publishQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.ready.Broadcast()
+}
+
+// drop drops the publishes left in the closed queue, and those still being
+// queued, instead of running them. It returns once none are left, though the
+// workers may still be running or dropping the ones they took.
+func (q *publishQueue) drop() {
+	q.dropping.Store(true)
+	q.work()
+}
+
+// depth returns the number of publishes waiting for a worker.
+func (q *publishQueue) depth() int {
+	return len(q.slots)
+}
+
+// Returns the number of publishes waiting for a worker when the bus was
+// created with WithQueueBusOpt, or 0 otherwise.
+func (b *bus) QueueDepth() int {
+	if b.queue == nil {
+		return 0
+	}
+	return b.queue.depth()
+}
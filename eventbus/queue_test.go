@@ -0,0 +1,237 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+)
+
+func TestPublish_WithQueue_ReturnsBeforeHandling(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithQueueBusOpt(1, 1))
+	release := make(chan struct{})
+	handled := make(chan struct{})
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		<-release
+		close(handled)
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+
+	close(release)
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Error("expected the queued event to be handled")
+	}
+}
+
+func TestPublish_WithFullQueue_BlocksUntilContextDone(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithQueueBusOpt(1, 1))
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	})
+	defer close(release)
+
+	// The first event occupies the worker, and the second fills the queue.
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	<-started
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	if depth := bus.QueueDepth(); depth != 1 {
+		t.Error("expected a queue depth of 1, got", depth)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected the publish to block until the deadline, got", err)
+	}
+}
+
+func TestClose_WithQueue_DrainsQueuedEventsBeforeWait(t *testing.T) {
+	ctx := context.Background()
+	metrics := newFakeMetrics()
+	bus := eventbus.New(eventbus.WithQueueBusOpt(100, 2), eventbus.WithMetricsBusOpt(metrics))
+	var handled atomic.Int64
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		time.Sleep(time.Millisecond)
+		handled.Add(1)
+		return nil
+	})
+
+	for i := 0; i < 50; i++ {
+		if err := bus.Publish(ctx, testEvent, nil); err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+	}
+	bus.Close()
+	bus.Wait(ctx)
+
+	if n := handled.Load(); n != 50 {
+		t.Error("expected all 50 queued events to be handled, got", n)
+	}
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, eventbus.ErrBusClosed) {
+		t.Error("expected ErrBusClosed, got", err)
+	}
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if len(metrics.depths) == 0 {
+		t.Error("expected the queue depth to be reported")
+	}
+}
+
+func TestClose_WithDrainTimeoutExceeded_DeadLettersQueuedEvents(t *testing.T) {
+	ctx := context.Background()
+	clock := newFakeClock()
+	var mu sync.Mutex
+	var deadLettered []error
+	bus := eventbus.New(
+		eventbus.WithClockBusOpt(clock),
+		eventbus.WithQueueBusOpt(10, 1),
+		eventbus.WithCloseDrainTimeoutBusOpt(time.Second),
+		eventbus.WithDeadLetterBusOpt(func(_ context.Context, _ eventbus.Event, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			deadLettered = append(deadLettered, err)
+		}),
+	)
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+	var handled atomic.Int64
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		started <- struct{}{}
+		<-release
+		handled.Add(1)
+		return nil
+	})
+
+	// The first event occupies the worker, and the rest back up the queue.
+	for i := 0; i < 4; i++ {
+		if err := bus.Publish(ctx, testEvent, nil); err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+	}
+	<-started
+
+	closed := make(chan struct{})
+	go func() {
+		bus.Close()
+		close(closed)
+	}()
+	clock.waitForTickers(1)
+	clock.Advance(time.Second)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("expected Close to return once the drain timeout elapsed")
+	}
+	close(release)
+	bus.Flush(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(deadLettered) != 3 {
+		t.Fatal("expected the queued events to be dead-lettered, got", len(deadLettered))
+	}
+	if !errors.Is(deadLettered[0], eventbus.ErrBusClosed) {
+		t.Error("expected ErrBusClosed, got", deadLettered[0])
+	}
+	if n := handled.Load(); n != 1 {
+		t.Error("expected only the started event to be handled, got", n)
+	}
+}
+
+func TestClose_WithDrainTimeoutNotExceeded_HandlesQueuedEvents(t *testing.T) {
+	ctx := context.Background()
+	deadLettered := 0
+	bus := eventbus.New(
+		eventbus.WithClockBusOpt(newFakeClock()),
+		eventbus.WithQueueBusOpt(10, 1),
+		eventbus.WithCloseDrainTimeoutBusOpt(time.Second),
+		eventbus.WithDeadLetterBusOpt(func(context.Context, eventbus.Event, error) {
+			deadLettered++
+		}),
+	)
+	var handled atomic.Int64
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		handled.Add(1)
+		return nil
+	})
+
+	for i := 0; i < 4; i++ {
+		if err := bus.Publish(ctx, testEvent, nil); err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+	}
+	bus.Close()
+
+	if n := handled.Load(); n != 4 {
+		t.Error("expected Close to wait for the queued events, got", n)
+	}
+	if deadLettered != 0 {
+		t.Error("expected no events to be dead-lettered, got", deadLettered)
+	}
+}
+
+func TestPublish_WithQueueAndFairScheduling_OtherNameNotStarved(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithQueueBusOpt(200, 1), eventbus.WithFairSchedulingBusOpt())
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var order []string
+	record := func(_ context.Context, name eventbus.Stringer, _ interface{}) error {
+		<-release
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, name.String())
+		return nil
+	}
+	bus.On(EventName("a")).Do(record)
+	bus.On(EventName("b")).Do(record)
+
+	// The worker is held up by the first event while the rest queue up.
+	for i := 0; i < 100; i++ {
+		if err := bus.Publish(ctx, EventName("a"), nil); err != nil {
+			t.Fatal("expected no error, got", err)
+		}
+	}
+	if err := bus.Publish(ctx, EventName("b"), nil); err != nil {
+		t.Fatal("expected no error, got", err)
+	}
+	close(release)
+	bus.Close()
+	bus.Flush(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(order) != 101 {
+		t.Fatal("expected every event to be handled, got", len(order))
+	}
+	for i, name := range order {
+		if name == "b" {
+			// At most the running event and the next one of "a" go first.
+			if i > 2 {
+				t.Error("expected b to not wait behind the flood of a, got", i)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+)
+
+// rateLimit returns ErrRateLimited if the event's name is rate limited and
+// publishing it now would exceed the limit. With WithRateLimitWaitBusOpt, it
+// waits for the limiter instead, until ctx is done or the publish times out.
+func (b *bus) rateLimit(ctx context.Context, e Event) error {
+	l, ok := b.rateLimiters[e.Name.String()]
+	if !ok {
+		return nil
+	}
+
+	if !b.rateLimitWait {
+		if !l.Allow() {
+			return fmt.Errorf("%w: %s", ErrRateLimited, e.Name)
+		}
+		return nil
+	}
+
+	if e.publishTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.publishTimeout)
+		defer cancel()
+	}
+	if err := l.Wait(ctx); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrRateLimited, e.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+	"golang.org/x/time/rate"
+)
+
+func TestPublish_WithRateLimit_RejectsExcess(t *testing.T) {
+	ctx := context.Background()
+	other := EventName("other")
+	bus := eventbus.New(eventbus.WithRateLimitBusOpt(testEvent, rate.Every(time.Hour), 3))
+	handled := 0
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		handled++
+		return nil
+	})
+
+	rejected := 0
+	for i := 0; i < 5; i++ {
+		if err := bus.Publish(ctx, testEvent, nil); errors.Is(err, eventbus.ErrRateLimited) {
+			rejected++
+		} else if err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		if err := bus.Publish(ctx, other, nil); err != nil {
+			t.Error("expected unlimited names to be unaffected", err)
+		}
+	}
+
+	if handled != 3 || rejected != 2 {
+		t.Error("expected the burst to be handled and the excess rejected", handled, rejected)
+	}
+}
+
+func TestPublish_WithRateLimitWait_DelaysExcess(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(
+		eventbus.WithRateLimitBusOpt(testEvent, rate.Every(20*time.Millisecond), 1),
+		eventbus.WithRateLimitWaitBusOpt(),
+	)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := bus.Publish(ctx, testEvent, nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Error("expected the excess publishes to be delayed", elapsed)
+	}
+}
+
+func TestPublish_WithRateLimitWaitAndPublishTimeout_RejectsWhenTooLong(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(
+		eventbus.WithRateLimitBusOpt(testEvent, rate.Every(time.Hour), 1),
+		eventbus.WithRateLimitWaitBusOpt(),
+	)
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	err := bus.Publish(ctx, testEvent, nil, eventbus.WithPublishTimeoutEventOpt(10*time.Millisecond))
+	if !errors.Is(err, eventbus.ErrRateLimited) {
+		t.Error("expected ErrRateLimited", err)
+	}
+}
@@ -0,0 +1,80 @@
+package eventbus
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// record writes the event to the recorder, if one is configured, as a line of
+// JSON. Events redirected by WithUnhandledEventBusOpt are not recorded, since
+// replaying the original event redirects it again. Errors are reported to the
+// error handler.
+func (b *bus) record(ctx context.Context, e Event) {
+	if b.recorder == nil || e.unhandled {
+		return
+	}
+
+	line, err := json.Marshal(e)
+	if err == nil {
+		b.recorderMu.Lock()
+		_, err = b.recorder.Write(append(line, '\n'))
+		b.recorderMu.Unlock()
+	}
+	if err != nil {
+		b.handleError(ctx, fmt.Errorf("record error; event: %v: %w", e, err))
+	}
+}
+
+// Replay publishes the events recorded with WithRecorderBusOpt, read as JSON
+// lines from r, to the bus in order, with their original timestamps and
+// headers. Names are replayed as Name, so they match StringMatcher
+// subscriptions and subscriptions made with On(Name(...)). Malformed lines and
+// failed publishes don't stop the replay; their errors are returned joined,
+// with their line numbers. Replay stops if ctx is done between events.
+func Replay(ctx context.Context, b *bus, r io.Reader) error {
+	var errs []error
+	// Lines are read whole, however large the recorded event is.
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, rerr := br.ReadBytes('\n')
+		if rerr != nil && rerr != io.EOF {
+			errs = append(errs, fmt.Errorf("replay error; line %d: %w", n, rerr))
+			break
+		}
+
+		line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+		if len(line) > 0 {
+			if ctx.Err() != nil {
+				return joinErrors(append(errs, ctx.Err())...)
+			}
+			if err := replayLine(ctx, b, n, line); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if rerr == io.EOF {
+			break
+		}
+	}
+
+	return joinErrors(errs...)
+}
+
+// replayLine publishes the event recorded on line n.
+func replayLine(ctx context.Context, b *bus, n int, line []byte) error {
+	e, err := ParseEvent(line)
+	if err != nil {
+		return fmt.Errorf("replay error; line %d: %w", n, err)
+	}
+	opts := []eventOpt{WithTimestampEventOpt(e.Timestamp)}
+	for k, v := range e.Headers {
+		opts = append(opts, WithHeaderEventOpt(k, v))
+	}
+	if err := b.Publish(ctx, e.Name, e.Data, opts...); err != nil {
+		return fmt.Errorf("replay error; line %d, event: %v: %w", n, e, err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package eventbus_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+)
+
+func TestReplay_RecordedEvents_PublishedToNewBus(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	bus := eventbus.New(eventbus.WithRecorderBusOpt(&buf))
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i, name := range []string{"first", "second", "first"} {
+		if err := bus.Publish(ctx, EventName(name), float64(i), eventbus.WithTimestampEventOpt(ts)); err != nil {
+			t.Fatal("expected no error", err)
+		}
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 3 {
+		t.Fatal("expected a line per event", buf.String())
+	}
+
+	replayed := eventbus.New(eventbus.WithAuditSinkBusOpt(&memoryAuditSink{}))
+	var first, second atomic.Int64
+	var data []interface{}
+	replayed.When(eventbus.StringMatcher("first")).Do(func(_ context.Context, _ eventbus.Stringer, d interface{}) error {
+		first.Add(1)
+		data = append(data, d)
+		return nil
+	})
+	replayed.On(eventbus.Name("second")).Do(func(_ context.Context, _ eventbus.Stringer, d interface{}) error {
+		second.Add(1)
+		data = append(data, d)
+		return nil
+	})
+
+	if err := eventbus.Replay(ctx, replayed, &buf); err != nil {
+		t.Error("expected no error", err)
+	}
+	if first.Load() != 2 || second.Load() != 1 {
+		t.Error("expected every recorded event to be replayed", first.Load(), second.Load())
+	}
+	if len(data) != 3 || data[0] != 0.0 || data[1] != 1.0 || data[2] != 2.0 {
+		t.Error("expected the events to be replayed in order with their data", data)
+	}
+}
+
+func TestReplay_RecordedEvents_KeepTimestamps(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	ts := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := eventbus.New(eventbus.WithRecorderBusOpt(&buf)).Publish(ctx, testEvent, nil, eventbus.WithTimestampEventOpt(ts)); err != nil {
+		t.Fatal("expected no error", err)
+	}
+
+	sink := &memoryAuditSink{}
+	if err := eventbus.Replay(ctx, eventbus.New(eventbus.WithAuditSinkBusOpt(sink)), &buf); err != nil {
+		t.Error("expected no error", err)
+	}
+	if len(sink.events) != 1 || !sink.events[0].Timestamp.Equal(ts) {
+		t.Error("expected the original timestamp", sink.events)
+	}
+}
+
+func TestReplay_MalformedLine_ReportsLineAndContinues(t *testing.T) {
+	ctx := context.Background()
+	input := `{"id":"1","name":"first","data":null,"timestamp":"2020-01-02T03:04:05Z"}
+not json
+
+{"id":"2","name":"first","data":null,"timestamp":"2020-01-02T03:04:05Z"}
+`
+	bus := eventbus.New()
+	var called atomic.Int64
+	bus.When(eventbus.StringMatcher("first")).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		called.Add(1)
+		return nil
+	})
+
+	err := eventbus.Replay(ctx, bus, strings.NewReader(input))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Error("expected an error for line 2", err)
+	}
+	if called.Load() != 2 {
+		t.Error("expected the valid events to be replayed", called.Load())
+	}
+}
+
+func TestReplay_ContextCanceled_StopsBetweenEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	input := strings.Repeat(`{"id":"1","name":"first","data":null,"timestamp":"2020-01-02T03:04:05Z"}`+"\n", 3)
+	bus := eventbus.New()
+	var called atomic.Int64
+	bus.When(eventbus.StringMatcher("first")).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		called.Add(1)
+		cancel()
+		return nil
+	})
+
+	err := eventbus.Replay(ctx, bus, strings.NewReader(input))
+	if !errors.Is(err, context.Canceled) {
+		t.Error("expected canceled error", err)
+	}
+	if called.Load() != 1 {
+		t.Error("expected the replay to stop after the first event", called.Load())
+	}
+}
+
+func TestReplay_EventLargerThanScannerBuffer_Replayed(t *testing.T) {
+	ctx := context.Background()
+	var buf bytes.Buffer
+	bus := eventbus.New(eventbus.WithRecorderBusOpt(&buf))
+	large := strings.Repeat("x", 2<<20)
+	for _, data := range []string{large, "small"} {
+		if err := bus.Publish(ctx, testEvent, data); err != nil {
+			t.Fatal("expected no error", err)
+		}
+	}
+
+	replayed := eventbus.New()
+	var sizes []int
+	replayed.On(eventbus.Name(testEvent)).Do(func(_ context.Context, _ eventbus.Stringer, data interface{}) error {
+		sizes = append(sizes, len(data.(string)))
+		return nil
+	})
+	if err := eventbus.Replay(ctx, replayed, &buf); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if len(sizes) != 2 || sizes[0] != len(large) || sizes[1] != len("small") {
+		t.Error("expected every recorded event to be replayed", sizes)
+	}
+}
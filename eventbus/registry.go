@@ -0,0 +1,163 @@
+package eventbus
+
+import "sort"
+
+// registry is an immutable snapshot of the observers and subscriptions of a
+// bus. A snapshot is never modified once it has been stored on the bus;
+// registration and removal build a new snapshot and swap it in
+// (copy-on-write), which lets the publish path read it without locking.
+type registry struct {
+	observers     map[string]observerWithOptions
+	subscriptions map[Stringer][]*subscription
+	// critical holds the critical observers, which are also in observers, in
+	// registration order.
+	critical []observerWithOptions
+	// scan holds the subscriptions, by ID, that may match events other than
+	// the name they are keyed by: those added with When, and those widened
+	// with Or. All other subscriptions only match events of their key, so a
+	// publish looks them up by name instead of matching every subscription.
+	scan map[string]*subscription
+}
+
+func newRegistry() *registry {
+	return &registry{
+		observers:     make(map[string]observerWithOptions),
+		subscriptions: make(map[Stringer][]*subscription),
+		scan:          make(map[string]*subscription),
+	}
+}
+
+// clone returns a shallow copy of the registry. The subscription and critical
+// observer slices are shared with the original, so they must be replaced rather
+// than modified in place; addSubscription and addObserver do this.
+func (r *registry) clone() *registry {
+	c := &registry{
+		observers:     make(map[string]observerWithOptions, len(r.observers)),
+		subscriptions: make(map[Stringer][]*subscription, len(r.subscriptions)),
+		critical:      r.critical,
+		scan:          make(map[string]*subscription, len(r.scan)),
+	}
+	for id, o := range r.observers {
+		c.observers[id] = o
+	}
+	for key, subs := range r.subscriptions {
+		c.subscriptions[key] = subs
+	}
+	for id, s := range r.scan {
+		c.scan[id] = s
+	}
+	return c
+}
+
+func (r *registry) addObserver(o observerWithOptions) {
+	r.observers[o.id] = o
+	if o.opts.critical {
+		r.critical = append(r.critical[:len(r.critical):len(r.critical)], o)
+	}
+}
+
+func (r *registry) removeObserver(id string) bool {
+	o, ok := r.observers[id]
+	if !ok {
+		return false
+	}
+
+	delete(r.observers, id)
+	if o.opts.critical {
+		critical := make([]observerWithOptions, 0, len(r.critical)-1)
+		for _, c := range r.critical {
+			if c.id != id {
+				critical = append(critical, c)
+			}
+		}
+		r.critical = critical
+	}
+	return true
+}
+
+func (r *registry) addSubscription(key Stringer, s *subscription) {
+	subs := r.subscriptions[key]
+	r.subscriptions[key] = append(subs[:len(subs):len(subs)], s)
+}
+
+// scanSubscription marks the subscription as one that publishes must match
+// against every event, if it is registered. It reports whether it is.
+func (r *registry) scanSubscription(s *subscription) bool {
+	for _, subs := range r.subscriptions {
+		for _, t := range subs {
+			if t == s {
+				r.scan[s.id] = s
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// moveSubscription moves the subscription with the provided ID to index to
+// among the subscriptions sharing its key, clamping the index to the valid
+// range. It reports whether the subscription was found.
+func (r *registry) moveSubscription(id string, to int) bool {
+	for key, subs := range r.subscriptions {
+		for i, s := range subs {
+			if s.id != id {
+				continue
+			}
+
+			if to < 0 {
+				to = 0
+			}
+			if to >= len(subs) {
+				to = len(subs) - 1
+			}
+
+			moved := make([]*subscription, 0, len(subs))
+			moved = append(moved, subs[:i]...)
+			moved = append(moved, subs[i+1:]...)
+			moved = append(moved[:to], append([]*subscription{s}, moved[to:]...)...)
+			r.subscriptions[key] = moved
+
+			// Handlers run in registration order, so hand the sequence numbers
+			// out again in the new order.
+			seqs := make([]uint64, len(subs))
+			for j, s := range subs {
+				seqs[j] = s.seq.Load()
+			}
+			sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+			for j, s := range moved {
+				s.seq.Store(seqs[j])
+			}
+			return true
+		}
+	}
+
+	return false
+}
+
+// removeSubscription removes the subscription with the provided ID, replacing
+// the slice it belonged to so that publishes iterating the previous snapshot
+// are unaffected. It reports whether the subscription was found.
+func (r *registry) removeSubscription(id string) bool {
+	for key, subs := range r.subscriptions {
+		for i, s := range subs {
+			if s.id != id {
+				continue
+			}
+
+			delete(r.scan, id)
+			if len(subs) == 1 {
+				delete(r.subscriptions, key)
+				return true
+			}
+
+			removed := make([]*subscription, 0, len(subs)-1)
+			removed = append(removed, subs[:i]...)
+			removed = append(removed, subs[i+1:]...)
+			r.subscriptions[key] = removed
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+package eventbus
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// rwMutexRegistry is the lock-based alternative to the copy-on-write registry,
+// kept here as a baseline for the read benchmarks.
+type rwMutexRegistry struct {
+	mu sync.RWMutex
+	r  *registry
+}
+
+func (m *rwMutexRegistry) subscriptions(key Stringer) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.r.subscriptions[key])
+}
+
+func benchmarkRegistry() (*bus, Stringer) {
+	eb := New()
+	for i := 0; i < 100; i++ {
+		eb.On(noMatch(strconv.Itoa(i)))
+	}
+	return eb, noMatch("50")
+}
+
+func BenchmarkRegistryRead_CopyOnWrite(b *testing.B) {
+	eb, key := benchmarkRegistry()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if len(eb.load().subscriptions[key]) != 1 {
+				b.Fatal("expected one subscription")
+			}
+		}
+	})
+}
+
+func BenchmarkRegistryRead_RWMutex(b *testing.B) {
+	eb, key := benchmarkRegistry()
+	m := &rwMutexRegistry{r: eb.load()}
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if m.subscriptions(key) != 1 {
+				b.Fatal("expected one subscription")
+			}
+		}
+	})
+}
+
+func TestUpdate_DoesNotModifyPreviousSnapshot(t *testing.T) {
+	eb := New()
+	key := noMatch("key")
+	eb.On(key)
+
+	before := eb.load()
+	eb.On(key)
+	eb.AddObserver(nil)
+
+	if len(before.subscriptions[key]) != 1 {
+		t.Error("expected previous snapshot to keep 1 subscription", len(before.subscriptions[key]))
+	}
+	if len(before.observers) != 0 {
+		t.Error("expected previous snapshot to have no observers", len(before.observers))
+	}
+	if len(eb.load().subscriptions[key]) != 2 {
+		t.Error("expected current snapshot to have 2 subscriptions", len(eb.load().subscriptions[key]))
+	}
+}
@@ -0,0 +1,94 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type (
+	// SubscriptionResult is what a subscription did with a published event.
+	// Err and Duration are zero for subscriptions that didn't match.
+	SubscriptionResult struct {
+		SubscriptionID string
+		Matched        bool
+		Err            error
+		Duration       time.Duration
+	}
+
+	// ObserverResult is what an observer did with a published event.
+	ObserverResult struct {
+		ObserverID string
+		Err        error
+		Duration   time.Duration
+	}
+
+	// PublishReport lists the result of every subscription and of every
+	// notified observer for a publish.
+	PublishReport struct {
+		Subscriptions []SubscriptionResult
+		Observers     []ObserverResult
+	}
+
+	// publishReport collects a PublishReport while an event is dispatched.
+	publishReport struct {
+		mu     sync.Mutex
+		report PublishReport
+	}
+)
+
+// Publishes an event like Publish, and reports the result of every
+// subscription and observer. Events published with WithAsyncEventOpt return
+// before they are dispatched, so their report is empty.
+func (b *bus) PublishResult(ctx context.Context, name Stringer, data interface{}, opts ...eventOpt) (PublishReport, error) {
+	r := &publishReport{}
+	err := b.publishEvent(ctx, name, data, r, opts)
+	return r.get(), err
+}
+
+// seed adds an unfinished result for every subscription the trace lists.
+func (r *publishReport) seed(trace MatchTrace) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, d := range trace {
+		r.report.Subscriptions = append(r.report.Subscriptions, SubscriptionResult{
+			SubscriptionID: d.SubscriptionID,
+			Matched:        d.Matched,
+		})
+	}
+}
+
+// subscription records the result of a subscription, replacing its seeded
+// result if there is one.
+func (r *publishReport) subscription(res SubscriptionResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.report.Subscriptions {
+		if r.report.Subscriptions[i].SubscriptionID == res.SubscriptionID {
+			r.report.Subscriptions[i] = res
+			return
+		}
+	}
+	r.report.Subscriptions = append(r.report.Subscriptions, res)
+}
+
+// observer records the result of an observer.
+func (r *publishReport) observer(res ObserverResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.report.Observers = append(r.report.Observers, res)
+}
+
+// get returns a copy of the report collected so far.
+func (r *publishReport) get() PublishReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return PublishReport{
+		Subscriptions: append([]SubscriptionResult{}, r.report.Subscriptions...),
+		Observers:     append([]ObserverResult{}, r.report.Observers...),
+	}
+}
@@ -0,0 +1,93 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+)
+
+func TestPublishResult_SucceedingAndFailingSubscribers_ReportsBoth(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithContinueOnErrorBusOpt())
+	errFailed := errors.New("failed")
+	ok := bus.On(testEvent)
+	ok.Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	failing := bus.On(testEvent)
+	failing.Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		return errFailed
+	})
+	other := bus.On(EventName("other"))
+
+	report, err := bus.PublishResult(ctx, testEvent, nil)
+	if !errors.Is(err, errFailed) {
+		t.Error("expected the failing subscriber's error", err)
+	}
+
+	results := make(map[string]eventbus.SubscriptionResult)
+	for _, r := range report.Subscriptions {
+		results[r.SubscriptionID] = r
+	}
+	if len(results) != 3 {
+		t.Fatal("expected a result for every subscription", report.Subscriptions)
+	}
+	if r := results[ok.String()]; !r.Matched || r.Err != nil || r.Duration < 20*time.Millisecond {
+		t.Error("expected succeeding subscriber to be reported with its duration", r)
+	}
+	if r := results[failing.String()]; !r.Matched || !errors.Is(r.Err, errFailed) || r.Duration >= 20*time.Millisecond {
+		t.Error("expected failing subscriber to be reported with its error", r)
+	}
+	if r := results[other.String()]; r.Matched || r.Err != nil || r.Duration != 0 {
+		t.Error("expected other subscription to be reported as not matched", r)
+	}
+}
+
+func TestPublishResult_Observers_ReportsEachObserver(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	errFailed := errors.New("failed")
+	okID := bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {
+		time.Sleep(10 * time.Millisecond)
+	}))
+	failingID := bus.AddErrorObserver(errorObserverFunc(func(context.Context, eventbus.Stringer, interface{}) error {
+		return errFailed
+	}))
+
+	report, _ := bus.PublishResult(ctx, testEvent, nil)
+
+	results := make(map[string]eventbus.ObserverResult)
+	for _, r := range report.Observers {
+		results[r.ObserverID] = r
+	}
+	if len(results) != 2 {
+		t.Fatal("expected a result for every observer", report.Observers)
+	}
+	if r := results[okID]; r.Err != nil || r.Duration < 10*time.Millisecond {
+		t.Error("expected succeeding observer to be reported with its duration", r)
+	}
+	if r := results[failingID]; !errors.Is(r.Err, errFailed) {
+		t.Error("expected failing observer to be reported with its error", r)
+	}
+}
+
+func TestPublishResult_WithAsyncOption_ReportsNothing(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		return nil
+	})
+
+	report, err := bus.PublishResult(ctx, testEvent, nil, eventbus.WithAsyncEventOpt())
+	bus.Flush(ctx)
+	if err != nil {
+		t.Error("expected no error", err)
+	}
+	if len(report.Subscriptions) != 0 || len(report.Observers) != 0 {
+		t.Error("expected an empty report", report)
+	}
+}
@@ -0,0 +1,62 @@
+package eventbus
+
+import (
+	"context"
+	"time"
+)
+
+// retryPolicy controls how often a subscription's handlers are retried.
+type retryPolicy struct {
+	attempts    int
+	backoff     time.Duration
+	shouldRetry func(error) bool
+	// backoffFunc, if set, returns the wait after the given failed attempt,
+	// instead of backoff.
+	backoffFunc func(attempt int) time.Duration
+}
+
+// wait returns how long to wait after the given failed attempt.
+func (p *retryPolicy) wait(attempt int) time.Duration {
+	if p.backoffFunc != nil {
+		return p.backoffFunc(attempt)
+	}
+	return p.backoff
+}
+
+// retry invokes fn until it succeeds, the policy's attempts are used up, or it
+// fails with an error the policy doesn't retry. A nil policy invokes fn once.
+func (b *bus) retry(ctx context.Context, e Event, p *retryPolicy, fn Handler) error {
+	err := b.invoke(ctx, e, fn)
+	if p == nil {
+		return err
+	}
+
+	for attempt := 1; err != nil && attempt < p.attempts; attempt++ {
+		if p.shouldRetry != nil && !p.shouldRetry(err) {
+			return err
+		}
+		if waitErr := b.sleep(ctx, p.wait(attempt)); waitErr != nil {
+			return err
+		}
+		err = b.invoke(ctx, e, fn)
+	}
+
+	return err
+}
+
+// sleep waits for d on the bus clock, returning early if ctx is done.
+func (b *bus) sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := b.clock.NewTicker(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C():
+		return nil
+	}
+}
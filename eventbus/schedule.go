@@ -0,0 +1,104 @@
+package eventbus
+
+import "sync"
+
+type (
+	// fairQueue runs queued work on up to max workers, taking turns between
+	// keys so that a flood of work for one key can't starve the others.
+	// Workers are started as work is queued and exit once the queue is empty.
+	fairQueue struct {
+		mu      sync.Mutex
+		max     int
+		workers int
+		pending turns[func()]
+	}
+
+	// turns holds pending items by key, handing them out taking turns between
+	// the keys, and in the order they were added within a key.
+	turns[T any] struct {
+		pending map[string][]T
+		// keys lists the keys with pending items, in the order they take
+		// turns.
+		keys []string
+	}
+)
+
+// newFairQueue returns a queue that runs up to max pieces of work at once. A
+// non-positive max doesn't limit the workers.
+func newFairQueue(max int) *fairQueue {
+	return &fairQueue{max: max}
+}
+
+// push queues fn behind the pending work for key.
+func (q *fairQueue) push(key string, fn func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.pending.push(key, fn)
+	if q.max <= 0 || q.workers < q.max {
+		q.workers++
+		go q.work()
+	}
+}
+
+// next returns the oldest work of the key whose turn it is. It returns false,
+// and the calling worker must exit, if there is no pending work.
+func (q *fairQueue) next() (func(), bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	fn, ok := q.pending.next()
+	if !ok {
+		q.workers--
+	}
+	return fn, ok
+}
+
+func (q *fairQueue) work() {
+	for {
+		fn, ok := q.next()
+		if !ok {
+			return
+		}
+		fn()
+	}
+}
+
+// push adds item behind the pending items of key.
+func (t *turns[T]) push(key string, item T) {
+	if t.pending == nil {
+		t.pending = make(map[string][]T)
+	}
+	if len(t.pending[key]) == 0 {
+		t.keys = append(t.keys, key)
+	}
+	t.pending[key] = append(t.pending[key], item)
+}
+
+// next removes and returns the oldest item of the key whose turn it is,
+// sending the key to the back of the line. It returns false if there are no
+// pending items.
+func (t *turns[T]) next() (T, bool) {
+	var zero T
+	if len(t.keys) == 0 {
+		return zero, false
+	}
+
+	key := t.keys[0]
+	t.keys = t.keys[1:]
+	items := t.pending[key]
+	item := items[0]
+	if len(items) == 1 {
+		delete(t.pending, key)
+	} else {
+		items[0] = zero
+		t.pending[key] = items[1:]
+		t.keys = append(t.keys, key)
+	}
+	return item, true
+}
+
+// empty reports whether there are no pending items.
+func (t *turns[T]) empty() bool {
+	return len(t.keys) == 0
+}
@@ -0,0 +1,81 @@
+package eventbus_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+)
+
+func TestPublish_WithFairSchedulingAndFloodedName_OtherNameNotStarved(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithFairSchedulingBusOpt(), eventbus.WithMaxConcurrencyBusOpt(1))
+	var mu sync.Mutex
+	var order []string
+	record := func(_ context.Context, name eventbus.Stringer, _ interface{}) error {
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		order = append(order, name.String())
+		mu.Unlock()
+		return nil
+	}
+	bus.On(EventName("a")).Do(record)
+	bus.On(EventName("b")).Do(record)
+
+	for i := 0; i < 100; i++ {
+		if err := bus.Publish(ctx, EventName("a"), nil, eventbus.WithAsyncEventOpt()); err != nil {
+			t.Fatal("expected no error", err)
+		}
+	}
+	if err := bus.Publish(ctx, EventName("b"), nil, eventbus.WithAsyncEventOpt()); err != nil {
+		t.Fatal("expected no error", err)
+	}
+	bus.Flush(ctx)
+
+	if len(order) != 101 {
+		t.Fatal("expected every event to be handled", len(order))
+	}
+	for i, name := range order {
+		if name == "b" {
+			// At most the running event and the next one of "a" go first.
+			if i > 2 {
+				t.Error("expected b to not wait behind the flood of a", i)
+			}
+			return
+		}
+	}
+}
+
+func TestPublish_WithFairScheduling_LimitsConcurrentAsyncPublishes(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithFairSchedulingBusOpt(), eventbus.WithMaxConcurrencyBusOpt(2))
+	var mu sync.Mutex
+	var running, peak int
+	bus.When(eventbus.AllMatcher{}).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+		time.Sleep(2 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+
+	for i := 0; i < 20; i++ {
+		name := EventName([]string{"a", "b", "c"}[i%3])
+		if err := bus.Publish(ctx, name, nil, eventbus.WithAsyncEventOpt()); err != nil {
+			t.Fatal("expected no error", err)
+		}
+	}
+	bus.Flush(ctx)
+
+	if peak != 2 {
+		t.Error("expected at most 2 async publishes at once", peak)
+	}
+}
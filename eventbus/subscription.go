@@ -1,36 +1,297 @@
 package eventbus
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
-type subscription struct {
-	id       string
-	matchers []Matcher
-	funcs    []func(context.Context, Stringer, interface{}) error
+type (
+	// Handler handles an event published with the provided name and data.
+	Handler func(ctx context.Context, name Stringer, data interface{}) error
+
+	subscription struct {
+		id     string
+		bus    *bus
+		seq    atomic.Uint64
+		mu     sync.Mutex
+		config atomic.Pointer[subscriptionConfig]
+		fired  atomic.Bool
+		// active is when, in Unix nanoseconds on the bus clock, the
+		// subscription last matched an event, or was registered if it never
+		// did.
+		active atomic.Int64
+		// group is the subscription group set by OnGroup, if any.
+		group string
+	}
+
+	// subscriptionConfig is an immutable snapshot of a subscription's matchers
+	// and handlers. Like the bus registry, it is replaced rather than modified
+	// so that a subscription can be configured while events are published.
+	subscriptionConfig struct {
+		matchers []Matcher
+		funcs    []Handler
+		finally  []func(context.Context, Stringer, interface{}, error)
+		retry    *retryPolicy
+		once     bool
+		priority int
+		isolated bool
+		// transform remaps the event before the handlers see it.
+		transform func(Stringer, interface{}) (Stringer, interface{})
+	}
+)
+
+func newSubscription(b *bus, id string, matchers ...Matcher) *subscription {
+	s := &subscription{id: id, bus: b}
+	s.seq.Store(b.seq.Add(1))
+	s.active.Store(b.clock.Now().UnixNano())
+	s.config.Store(&subscriptionConfig{matchers: matchers})
+	return s
+}
+
+// cloneTo returns a copy of the subscription registered on b, sharing its
+// configuration.
+func (s *subscription) cloneTo(b *bus) *subscription {
+	c := &subscription{id: s.id, bus: b, group: s.group}
+	c.seq.Store(s.seq.Load())
+	c.active.Store(s.active.Load())
+	c.config.Store(s.load())
+	return c
+}
+
+// clone returns a copy of the config whose slices are capped at their length,
+// so appending to them allocates instead of writing into shared memory.
+func (c *subscriptionConfig) clone() *subscriptionConfig {
+	clone := *c
+	clone.matchers = c.matchers[:len(c.matchers):len(c.matchers)]
+	clone.funcs = c.funcs[:len(c.funcs):len(c.funcs)]
+	clone.finally = c.finally[:len(c.finally):len(c.finally)]
+	return &clone
+}
+
+func (c *subscriptionConfig) match(ctx context.Context, name Stringer, data interface{}) bool {
+	_, ok := c.matcher(ctx, name, data)
+	return ok
+}
+
+// matcher returns the first matcher that matches the event published with ctx.
+func (c *subscriptionConfig) matcher(ctx context.Context, name Stringer, data interface{}) (Matcher, bool) {
+	for _, m := range c.matchers {
+		if matchContext(ctx, m, name, data) {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+func (s *subscription) load() *subscriptionConfig {
+	return s.config.Load()
+}
+
+func (s *subscription) update(fn func(c *subscriptionConfig)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c := s.config.Load().clone()
+	fn(c)
+	s.config.Store(c)
 }
 
 // Or returns a new subscription that is the logical OR of the provided
 // matchers.
 func (s *subscription) Or(matcher Matcher) *subscription {
-	s.matchers = append(s.matchers, matcher)
+	// The subscription may now match events other than the name it was
+	// registered with, so it can't be looked up by name anymore.
+	s.bus.update(func(r *registry) {
+		r.scanSubscription(s)
+	})
+	s.update(func(c *subscriptionConfig) {
+		c.matchers = append(c.matchers, matcher)
+	})
+	return s
+}
+
+// Retries each of the subscription's failing handlers up to attempts times in
+// total, waiting backoff between attempts, as long as shouldRetry returns true
+// for the error. A nil shouldRetry retries every error.
+func (s *subscription) WithRetryIf(attempts int, backoff time.Duration, shouldRetry func(error) bool) *subscription {
+	s.update(func(c *subscriptionConfig) {
+		c.retry = &retryPolicy{attempts: attempts, backoff: backoff, shouldRetry: shouldRetry}
+	})
+	return s
+}
+
+// Retries each of the subscription's failing handlers up to attempts times in
+// total, with the same event. After attempt n fails, it waits backoff(n),
+// unless the publish is done first; a nil backoff doesn't wait. If every
+// attempt fails, the last error is returned.
+func (s *subscription) WithRetry(attempts int, backoff func(attempt int) time.Duration) *subscription {
+	s.update(func(c *subscriptionConfig) {
+		c.retry = &retryPolicy{attempts: attempts, backoffFunc: backoff}
+	})
+	return s
+}
+
+// Once removes the subscription after its handlers first complete without
+// error for a matching event. Concurrent publishes run its handlers at most
+// once; if a handler fails, the subscription stays for the next event.
+func (s *subscription) Once() *subscription {
+	s.update(func(c *subscriptionConfig) {
+		c.once = true
+	})
+	return s
+}
+
+// WithIsolatedHandlers runs every handler of the subscription even when one
+// fails, instead of skipping the handlers after it. Their errors are joined.
+func (s *subscription) WithIsolatedHandlers() *subscription {
+	s.update(func(c *subscriptionConfig) {
+		c.isolated = true
+	})
+	return s
+}
+
+// Transform remaps the name and data of matching events before the
+// subscription's handlers see them, for example to normalize legacy event
+// names. Matching, other subscriptions and observers see the original event.
+func (s *subscription) Transform(fn func(Stringer, interface{}) (Stringer, interface{})) *subscription {
+	s.update(func(c *subscriptionConfig) {
+		c.transform = fn
+	})
+	return s
+}
+
+// Priority sets the order in which the subscription runs relative to other
+// subscriptions matching the same event. Higher priorities run first, and
+// subscriptions with equal priorities run in registration order. The default
+// priority is 0.
+func (s *subscription) Priority(priority int) *subscription {
+	s.update(func(c *subscriptionConfig) {
+		c.priority = priority
+	})
+	return s
+}
+
+// And requires the matcher to match along with the most recently added
+// matcher. Matchers form OR-ed groups of AND-ed matchers, so On(a).Or(b).And(c)
+// matches events that match a, or both b and c.
+func (s *subscription) And(matcher Matcher) *subscription {
+	s.update(func(c *subscriptionConfig) {
+		if len(c.matchers) == 0 {
+			c.matchers = append(c.matchers, matcher)
+			return
+		}
+
+		last := len(c.matchers) - 1
+		group, ok := c.matchers[last].(andMatcher)
+		if !ok {
+			group = andMatcher{c.matchers[last]}
+		}
+		// The config's matchers are shared with previous snapshots.
+		matchers := append([]Matcher{}, c.matchers...)
+		matchers[last] = append(group[:len(group):len(group)], matcher)
+		c.matchers = matchers
+	})
 	return s
 }
 
 // Assigns the function to be executed when the event is published.
-func (s *subscription) Do(fn func(context.Context, Stringer, interface{}) error) {
-	s.funcs = append(s.funcs, fn)
+func (s *subscription) Do(fn Handler) {
+	s.update(func(c *subscriptionConfig) {
+		c.funcs = append(c.funcs, fn)
+	})
+}
+
+// Replace swaps all of the subscription's handlers for fns at once, keeping its
+// ID, matchers and position. Publishes already running the old handlers finish
+// with them; later publishes run only the new ones.
+func (s *subscription) Replace(fns ...Handler) {
+	s.update(func(c *subscriptionConfig) {
+		c.funcs = append([]Handler{}, fns...)
+	})
+}
+
+// Finally assigns a function to be executed after the subscription's handlers
+// for every matching event, like a deferred cleanup. It receives the error of
+// the handlers, or nil if they all succeeded, and runs even when a handler
+// failed and aborted the rest.
+func (s *subscription) Finally(fn func(context.Context, Stringer, interface{}, error)) {
+	s.update(func(c *subscriptionConfig) {
+		c.finally = append(c.finally, fn)
+	})
+}
+
+// Assigns a pipeline of functions to be executed when the event is published.
+// Each function receives the data returned by the previous one, with the first
+// receiving the event data. The first error aborts the pipeline.
+func (s *subscription) DoPipeline(fns ...func(context.Context, Stringer, interface{}) (interface{}, error)) {
+	s.Do(func(ctx context.Context, name Stringer, data interface{}) error {
+		for _, fn := range fns {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			var err error
+			if data, err = fn(ctx, name, data); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Assigns a function to be executed when the event is published, with JSON
+// data decoded first. When the data is a []byte, json.RawMessage or string, it
+// is unmarshaled into a fresh target() and fn receives that instead; other
+// data is passed as is. A decoding error fails the handler.
+func (s *subscription) DecodeJSON(target func() interface{}, fn Handler) {
+	s.Do(func(ctx context.Context, name Stringer, data interface{}) error {
+		var raw []byte
+		switch d := data.(type) {
+		case []byte:
+			raw = d
+		case json.RawMessage:
+			raw = d
+		case string:
+			raw = []byte(d)
+		default:
+			return fn(ctx, name, data)
+		}
+
+		v := target()
+		if err := json.Unmarshal(raw, v); err != nil {
+			return fmt.Errorf("decode event data: %w", err)
+		}
+		return fn(ctx, name, v)
+	})
+}
+
+// Removes the subscription from the bus it was created on. Returns false if it
+// was already removed.
+func (s *subscription) Unsubscribe() bool {
+	return s.bus.Unsubscribe(s)
 }
 
 // Match returns true if the event matches the subscription.
 func (s *subscription) Match(name Stringer, data interface{}) bool {
-	for _, m := range s.matchers {
-		if m.Match(name, data) {
-			return true
-		}
-	}
-	return false
+	return s.load().match(context.Background(), name, data)
 }
 
 // String returns the subscription's ID.
 func (s *subscription) String() string {
 	return s.id
 }
+
+// Describe returns the subscription's ID along with its matchers and handler
+// count, e.g. "sub[abc123] matchers=[^user.*$] handlers=2".
+func (s *subscription) Describe() string {
+	c := s.load()
+	matchers := make([]string, len(c.matchers))
+	for i, m := range c.matchers {
+		matchers[i] = m.String()
+	}
+	return fmt.Sprintf("sub[%s] matchers=%v handlers=%d", s.id, matchers, len(c.funcs))
+}
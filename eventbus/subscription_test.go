@@ -0,0 +1,863 @@
+package eventbus_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+	"golang.org/x/exp/slog"
+)
+
+func TestDoPipeline_EventPublished_PassesDataThroughStages(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var stored interface{}
+	bus.On(testEvent).DoPipeline(
+		func(_ context.Context, _ eventbus.Stringer, data interface{}) (interface{}, error) {
+			return strconv.Atoi(data.(string))
+		},
+		func(_ context.Context, _ eventbus.Stringer, data interface{}) (interface{}, error) {
+			if data.(int) < 0 {
+				return nil, errors.New("negative")
+			}
+			return data, nil
+		},
+		func(_ context.Context, _ eventbus.Stringer, data interface{}) (interface{}, error) {
+			stored = data
+			return nil, nil
+		},
+	)
+
+	if err := bus.Publish(ctx, testEvent, "42"); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if stored != 42 {
+		t.Error("expected parsed data to reach the last stage", stored)
+	}
+}
+
+func TestDoPipeline_StageReturnsError_StopsPipeline(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var called []string
+	bus.On(testEvent).DoPipeline(
+		func(_ context.Context, _ eventbus.Stringer, data interface{}) (interface{}, error) {
+			called = append(called, "parse")
+			return data, nil
+		},
+		func(_ context.Context, _ eventbus.Stringer, _ interface{}) (interface{}, error) {
+			called = append(called, "validate")
+			return nil, errors.New("invalid")
+		},
+		func(_ context.Context, _ eventbus.Stringer, data interface{}) (interface{}, error) {
+			called = append(called, "store")
+			return data, nil
+		},
+	)
+
+	if err := bus.Publish(ctx, testEvent, nil); err == nil || err.Error() != "invalid" {
+		t.Error("expected error", err)
+	}
+
+	if len(called) != 2 || called[1] != "validate" {
+		t.Error("expected pipeline to stop after validate", called)
+	}
+}
+
+func TestPublish_WithWarnEmptySubscriptions_LogsEmptyMatch(t *testing.T) {
+	logs := captureDefaultLog(t)
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithWarnEmptySubscriptionsBusOpt())
+	s := bus.On(testEvent)
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	warnings := logs.find("matched subscription has no handlers")
+	if len(warnings) != 1 {
+		t.Fatal("expected a warning to be logged", logs.messages)
+	}
+	if warnings[0]["subscription"] != s.String() {
+		t.Error("expected the subscription ID to be logged", warnings[0])
+	}
+	if warnings[0][slog.LevelKey] != slog.LevelWarn.String() {
+		t.Error("expected the warning to be logged at warn level", warnings[0][slog.LevelKey])
+	}
+}
+
+func TestPublish_WithoutWarnEmptySubscriptions_LogsNoWarning(t *testing.T) {
+	logs := captureDefaultLog(t)
+	ctx := context.Background()
+	bus := eventbus.New()
+	bus.On(testEvent)
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if warnings := logs.find("matched subscription has no handlers"); len(warnings) != 0 {
+		t.Error("expected no warning to be logged", logs.messages)
+	}
+}
+
+func TestMoveSubscription_ThirdMovedToFirst_ChangesExecutionOrder(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var called []string
+	for _, name := range []string{"first", "second", "third"} {
+		name := name
+		s := bus.On(testEvent)
+		s.Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+			called = append(called, name)
+			return nil
+		})
+		if name == "third" {
+			if !bus.MoveSubscription(s.String(), 0) {
+				t.Error("expected subscription to be moved")
+			}
+		}
+	}
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if len(called) != 3 || called[0] != "third" || called[1] != "first" || called[2] != "second" {
+		t.Error("expected moved subscription to run first", called)
+	}
+}
+
+func TestMoveSubscription_UnknownID_ReturnsFalse(t *testing.T) {
+	bus := eventbus.New()
+	bus.On(testEvent)
+
+	if bus.MoveSubscription("unknown", 0) {
+		t.Error("expected unknown subscription to not be moved")
+	}
+}
+
+func TestPublish_WithMatchTrace_RecordsEachSubscriptionDecision(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	exact := bus.On(testEvent)
+	prefix := bus.When(eventbus.PrefixMatcher("te"))
+	or := bus.When(eventbus.PrefixMatcher("other")).Or(eventbus.SuffixMatcher("st"))
+	other := bus.On(EventName("other"))
+
+	var trace eventbus.MatchTrace
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithMatchTraceEventOpt(&trace)); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	decisions := make(map[string]eventbus.MatchDecision)
+	for _, d := range trace {
+		decisions[d.SubscriptionID] = d
+	}
+	if len(decisions) != 4 {
+		t.Fatal("expected a decision for every subscription", trace)
+	}
+	if d := decisions[exact.String()]; !d.Matched || d.Matcher != "predicate" {
+		t.Error("expected exact subscription to match", d)
+	}
+	if d := decisions[prefix.String()]; !d.Matched || d.Matcher != "te*" {
+		t.Error("expected prefix subscription to match", d)
+	}
+	if d := decisions[or.String()]; !d.Matched || d.Matcher != "*st" {
+		t.Error("expected second matcher of the or subscription to match", d)
+	}
+	if d := decisions[other.String()]; d.Matched || d.Matcher != "" {
+		t.Error("expected other subscription to not match", d)
+	}
+}
+
+func TestUnsubscribe_RemovedSubscription_IsNotCalled(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	called := 0
+	s := bus.On(testEvent)
+	s.Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called++
+		return nil
+	})
+	w := bus.When(ConstantMatcher{true})
+	w.Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		called++
+		return nil
+	})
+
+	if !bus.Unsubscribe(s) {
+		t.Error("expected subscription to be removed")
+	}
+	if !w.Unsubscribe() {
+		t.Error("expected subscription to be removed")
+	}
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if called != 0 {
+		t.Error("expected removed subscriptions to not be called", called)
+	}
+}
+
+func TestUnsubscribe_AlreadyRemoved_ReturnsFalse(t *testing.T) {
+	bus := eventbus.New()
+	s := bus.On(testEvent)
+	s.Unsubscribe()
+
+	if bus.Unsubscribe(s) {
+		t.Error("expected removed subscription to not be removed again")
+	}
+	if bus.Unsubscribe(nil) {
+		t.Error("expected nil subscription to not be removed")
+	}
+}
+
+func TestUnsubscribe_FromHandler_RemainingSubscriptionsStillCalled(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var called []string
+	var first interface{ Unsubscribe() bool }
+	for _, name := range []string{"first", "second", "third"} {
+		name := name
+		s := bus.On(testEvent)
+		s.Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+			called = append(called, name)
+			if name == "first" {
+				first.Unsubscribe()
+			}
+			return nil
+		})
+		if first == nil {
+			first = s
+		}
+	}
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	if len(called) != 3 || called[0] != "first" || called[1] != "second" || called[2] != "third" {
+		t.Error("expected every subscription to be called during the publish", called)
+	}
+
+	called = nil
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	if len(called) != 2 || called[0] != "second" || called[1] != "third" {
+		t.Error("expected removed subscription to not be called on the next publish", called)
+	}
+}
+
+func TestDescribe_IncludesIDMatchersAndHandlerCount(t *testing.T) {
+	bus := eventbus.New()
+	s := bus.When(eventbus.WildcardMatcher("user.*")).Or(eventbus.PrefixMatcher("order."))
+	s.Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error { return nil })
+	s.Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error { return nil })
+
+	expected := "sub[" + s.String() + `] matchers=[^user\..*$ order.*] handlers=2`
+	if s.Describe() != expected {
+		t.Error("expected description to include ID, matchers and handler count", s.Describe())
+	}
+}
+
+func TestPublish_WithContinueOnError_ErrorDescribesSubscription(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New(eventbus.WithContinueOnErrorBusOpt())
+	s := bus.On(testEvent)
+	s.Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error { return errors.New("failed") })
+
+	err := bus.Publish(ctx, testEvent, nil)
+	if err == nil || !strings.Contains(err.Error(), s.Describe()) {
+		t.Error("expected error to describe the failing subscription", err)
+	}
+}
+
+var errTransient = errors.New("transient")
+
+func TestWithRetryIf_RetriableError_RetriesUntilSuccess(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	calls := 0
+	bus.On(testEvent).
+		WithRetryIf(3, time.Millisecond, func(err error) bool { return errors.Is(err, errTransient) }).
+		Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+			calls++
+			if calls < 3 {
+				return errTransient
+			}
+			return nil
+		})
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	if calls != 3 {
+		t.Error("expected handler to be called 3 times", calls)
+	}
+}
+
+func TestWithRetryIf_AttemptsExhausted_ReturnsLastError(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	calls := 0
+	bus.On(testEvent).
+		WithRetryIf(2, 0, nil).
+		Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+			calls++
+			return errTransient
+		})
+
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, errTransient) {
+		t.Error("expected transient error", err)
+	}
+	if calls != 2 {
+		t.Error("expected handler to be called 2 times", calls)
+	}
+}
+
+func TestWithRetry_FailsTwiceThenSucceeds_WaitsPerBackoff(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var calls int
+	var waits []int
+	bus.On(testEvent).
+		WithRetry(5, func(attempt int) time.Duration {
+			waits = append(waits, attempt)
+			return time.Duration(attempt) * 10 * time.Millisecond
+		}).
+		Do(func(_ context.Context, _ eventbus.Stringer, data interface{}) error {
+			calls++
+			if data != "payload" {
+				t.Error("expected the same data on every attempt", data)
+			}
+			if calls < 3 {
+				return errTransient
+			}
+			return nil
+		})
+
+	start := time.Now()
+	if err := bus.Publish(ctx, testEvent, "payload"); err != nil {
+		t.Error("expected no error", err)
+	}
+	elapsed := time.Since(start)
+
+	if calls != 3 {
+		t.Error("expected handler to be called 3 times", calls)
+	}
+	if len(waits) != 2 || waits[0] != 1 || waits[1] != 2 {
+		t.Error("expected a backoff after each failed attempt", waits)
+	}
+	if elapsed < 30*time.Millisecond {
+		t.Error("expected the publish to wait for the backoffs", elapsed)
+	}
+}
+
+func TestWithRetry_AlwaysFails_ReturnsLastErrorAfterAllAttempts(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	calls := 0
+	bus.On(testEvent).
+		WithRetry(3, func(int) time.Duration { return 5 * time.Millisecond }).
+		Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+			calls++
+			return fmt.Errorf("attempt %d: %w", calls, errTransient)
+		})
+
+	start := time.Now()
+	err := bus.Publish(ctx, testEvent, nil)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, errTransient) || !strings.Contains(err.Error(), "attempt 3") {
+		t.Error("expected the last error", err)
+	}
+	if calls != 3 {
+		t.Error("expected handler to be called 3 times", calls)
+	}
+	if elapsed < 10*time.Millisecond {
+		t.Error("expected the publish to wait between attempts", elapsed)
+	}
+}
+
+func TestWithRetry_ContextCanceledDuringBackoff_StopsRetrying(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	bus := eventbus.New()
+	calls := 0
+	bus.On(testEvent).
+		WithRetry(3, func(int) time.Duration { return time.Minute }).
+		Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+			calls++
+			cancel()
+			return errTransient
+		})
+
+	if err := bus.Publish(ctx, testEvent, nil, eventbus.WithInlineHandlersEventOpt()); !errors.Is(err, errTransient) {
+		t.Error("expected the handler error", err)
+	}
+	if calls != 1 {
+		t.Error("expected handler to be called once", calls)
+	}
+}
+
+func TestWithRetryIf_NonRetriableError_FailsImmediately(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	errInvalid := errors.New("invalid")
+	calls := 0
+	bus.On(testEvent).
+		WithRetryIf(3, time.Millisecond, func(err error) bool { return errors.Is(err, errTransient) }).
+		Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+			calls++
+			return errInvalid
+		})
+
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, errInvalid) {
+		t.Error("expected invalid error", err)
+	}
+	if calls != 1 {
+		t.Error("expected handler to be called once", calls)
+	}
+}
+
+func TestOnce_BackToBackPublishes_OnlyFirstCallsHandler(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	calls := 0
+	bus.On(testEvent).Once().Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		calls++
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		if err := bus.Publish(ctx, testEvent, nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if calls != 1 {
+		t.Error("expected handler to be called once", calls)
+	}
+}
+
+func TestOnce_HandlerFails_SubscriptionRemains(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	calls := 0
+	s := bus.On(testEvent).Once()
+	s.Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		calls++
+		if calls == 1 {
+			return errTransient
+		}
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, errTransient) {
+		t.Error("expected transient error", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := bus.Publish(ctx, testEvent, nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if calls != 2 {
+		t.Error("expected handler to be called until it succeeds", calls)
+	}
+	if s.Unsubscribe() {
+		t.Error("expected subscription to be removed after succeeding")
+	}
+}
+
+func TestOnce_PublishedBeforeHandlerAttached_SubscriptionRemains(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	calls := 0
+	s := bus.On(testEvent).Once()
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	s.Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		calls++
+		return nil
+	})
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if calls != 1 {
+		t.Error("expected handler to be called once", calls)
+	}
+	if n := bus.SubscriptionCount(); n != 0 {
+		t.Error("expected subscription to be removed after firing", n)
+	}
+}
+
+func TestOnce_ConcurrentPublishes_CallsHandlerOnce(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var calls atomic.Int64
+	bus.On(testEvent).Once().Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+		calls.Add(1)
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := bus.Publish(ctx, testEvent, nil); err != nil {
+				t.Error("expected no error", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Error("expected handler to be called once", calls.Load())
+	}
+}
+
+func TestPriority_MixedPriorities_RunHighestFirstThenInRegistrationOrder(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var called []string
+	subscribe := func(name string, priority int) {
+		bus.On(testEvent).Priority(priority).Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+			called = append(called, name)
+			return nil
+		})
+	}
+	subscribe("low", -1)
+	subscribe("default1", 0)
+	subscribe("high1", 10)
+	subscribe("default2", 0)
+	subscribe("high2", 10)
+	subscribe("medium", 5)
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	expected := []string{"high1", "high2", "medium", "default1", "default2", "low"}
+	if strings.Join(called, ",") != strings.Join(expected, ",") {
+		t.Error("expected subscriptions to run by priority then registration order", called)
+	}
+}
+
+func TestPriority_AcrossMatchers_RunsInRegistrationOrder(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var called []string
+	for i := 0; i < 10; i++ {
+		name := strconv.Itoa(i)
+		var s interface{ Do(eventbus.Handler) }
+		if i%2 == 0 {
+			s = bus.On(testEvent)
+		} else {
+			s = bus.When(ConstantMatcher{true})
+		}
+		s.Do(func(_ context.Context, _ eventbus.Stringer, _ interface{}) error {
+			called = append(called, name)
+			return nil
+		})
+	}
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if strings.Join(called, ",") != "0,1,2,3,4,5,6,7,8,9" {
+		t.Error("expected subscriptions to run in registration order", called)
+	}
+}
+
+func TestReplace_AfterPublish_OnlyNewHandlersRun(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var called []string
+	handler := func(name string) eventbus.Handler {
+		return func(context.Context, eventbus.Stringer, interface{}) error {
+			called = append(called, name)
+			return nil
+		}
+	}
+	s := bus.On(testEvent)
+	s.Do(handler("old1"))
+	s.Do(handler("old2"))
+	id := s.String()
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	s.Replace(handler("new1"), handler("new2"))
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if got := strings.Join(called, ","); got != "old1,old2,new1,new2" {
+		t.Error("expected only the new handlers after Replace", got)
+	}
+	if s.String() != id || bus.SubscriptionCount() != 1 {
+		t.Error("expected the subscription to stay registered with its ID")
+	}
+}
+
+func TestFinally_HandlerSucceeds_RunsAfterWithNilError(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var called []string
+	s := bus.On(testEvent)
+	s.Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		called = append(called, "handler")
+		return nil
+	})
+	var ferr error
+	s.Finally(func(_ context.Context, _ eventbus.Stringer, _ interface{}, err error) {
+		called = append(called, "finally")
+		ferr = err
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if got := strings.Join(called, ","); got != "handler,finally" {
+		t.Error("expected Finally to run after the handler", got)
+	}
+	if ferr != nil {
+		t.Error("expected Finally to receive no error", ferr)
+	}
+}
+
+func TestFinally_HandlerFails_RunsWithError(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	handlerErr := errors.New("handler error")
+	var secondCalled bool
+	s := bus.On(testEvent)
+	s.Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		return handlerErr
+	})
+	s.Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		secondCalled = true
+		return nil
+	})
+	var ferr error
+	s.Finally(func(_ context.Context, _ eventbus.Stringer, _ interface{}, err error) {
+		ferr = err
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, handlerErr) {
+		t.Error("expected the handler error", err)
+	}
+
+	if secondCalled {
+		t.Error("expected the failing handler to abort the rest")
+	}
+	if !errors.Is(ferr, handlerErr) {
+		t.Error("expected Finally to receive the handler error", ferr)
+	}
+}
+
+func TestDecodeJSON_BytePayload_HandlerReceivesDecodedStruct(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	type user struct {
+		Name string `json:"name"`
+	}
+	var got []*user
+	bus.On(testEvent).DecodeJSON(func() interface{} { return &user{} }, func(_ context.Context, _ eventbus.Stringer, data interface{}) error {
+		got = append(got, data.(*user))
+		return nil
+	})
+
+	payloads := []interface{}{[]byte(`{"name":"a"}`), json.RawMessage(`{"name":"b"}`), `{"name":"c"}`}
+	for _, data := range payloads {
+		if err := bus.Publish(ctx, testEvent, data); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if len(got) != 3 || got[0].Name != "a" || got[1].Name != "b" || got[2].Name != "c" {
+		t.Error("expected the handler to receive the decoded structs", got)
+	}
+	if got[0] == got[1] {
+		t.Error("expected a fresh target for every event")
+	}
+}
+
+func TestDecodeJSON_InvalidPayload_ReturnsError(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	called := false
+	bus.On(testEvent).DecodeJSON(func() interface{} { return &struct{}{} }, func(context.Context, eventbus.Stringer, interface{}) error {
+		called = true
+		return nil
+	})
+
+	var syntaxErr *json.SyntaxError
+	if err := bus.Publish(ctx, testEvent, []byte("{")); !errors.As(err, &syntaxErr) {
+		t.Error("expected the decoding error", err)
+	}
+	if called {
+		t.Error("expected the handler to not be called")
+	}
+}
+
+func TestWithIsolatedHandlers_MiddleHandlerFails_RunsAllAndReportsError(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	handlerErr := errors.New("handler error")
+	var called []string
+	s := bus.On(testEvent).WithIsolatedHandlers()
+	for _, name := range []string{"first", "second", "third"} {
+		name := name
+		s.Do(func(context.Context, eventbus.Stringer, interface{}) error {
+			called = append(called, name)
+			if name == "second" {
+				return handlerErr
+			}
+			return nil
+		})
+	}
+
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, handlerErr) {
+		t.Error("expected the handler error", err)
+	}
+	if got := strings.Join(called, ","); got != "first,second,third" {
+		t.Error("expected all handlers to run", got)
+	}
+}
+
+func TestTransform_UppercasesName_OnlyThatSubscriptionSeesIt(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	type wrapped struct {
+		data interface{}
+	}
+	var transformedName, originalName eventbus.Stringer
+	var transformedData, originalData interface{}
+	bus.On(testEvent).
+		Transform(func(name eventbus.Stringer, data interface{}) (eventbus.Stringer, interface{}) {
+			return EventName(strings.ToUpper(name.String())), wrapped{data}
+		}).
+		Do(func(_ context.Context, name eventbus.Stringer, data interface{}) error {
+			transformedName, transformedData = name, data
+			return nil
+		})
+	bus.On(testEvent).Do(func(_ context.Context, name eventbus.Stringer, data interface{}) error {
+		originalName, originalData = name, data
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, "data"); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if transformedName != EventName("TEST") || transformedData != (wrapped{"data"}) {
+		t.Error("expected the transformed event", transformedName, transformedData)
+	}
+	if originalName != testEvent || originalData != "data" {
+		t.Error("expected other subscriptions to see the original event", originalName, originalData)
+	}
+}
+
+func TestOnGroup_ThreeMembers_EachEventHandledByOneInTurn(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	handled := make([]int, 3)
+	for i := range handled {
+		i := i
+		bus.OnGroup("workers", testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+			handled[i]++
+			return nil
+		})
+	}
+	ungrouped := 0
+	bus.On(testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		ungrouped++
+		return nil
+	})
+
+	for i := 0; i < 6; i++ {
+		if err := bus.Publish(ctx, testEvent, nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	for i, n := range handled {
+		if n != 2 {
+			t.Error("expected each member to handle 2 events", i, n)
+		}
+	}
+	if ungrouped != 6 {
+		t.Error("expected the ungrouped subscription to handle every event", ungrouped)
+	}
+}
+
+func TestPublishRequire_WithGroup_CountsGroupOnce(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	for i := 0; i < 3; i++ {
+		bus.OnGroup("workers", testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+			return nil
+		})
+	}
+
+	if err := bus.PublishRequire(ctx, testEvent, nil, 2); !errors.Is(err, eventbus.ErrInsufficientSubscribers) {
+		t.Error("expected the group to count as one subscriber", err)
+	}
+	if err := bus.PublishRequire(ctx, testEvent, nil, 1); err != nil {
+		t.Error("expected no error", err)
+	}
+}
+
+func TestPublishResult_WithGroup_ReportsOnlyPickedMemberAsMatched(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	for i := 0; i < 2; i++ {
+		bus.OnGroup("workers", testEvent).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+			return nil
+		})
+	}
+
+	var trace eventbus.MatchTrace
+	report, err := bus.PublishResult(ctx, testEvent, nil, eventbus.WithMatchTraceEventOpt(&trace))
+	if err != nil {
+		t.Error("expected no error", err)
+	}
+
+	matched := 0
+	for _, r := range report.Subscriptions {
+		if r.Matched {
+			matched++
+		}
+	}
+	if len(report.Subscriptions) != 2 || matched != 1 {
+		t.Error("expected only the picked member to be reported as matched", report.Subscriptions)
+	}
+	matched = 0
+	for _, d := range trace {
+		if d.Matched {
+			matched++
+		}
+	}
+	if len(trace) != 2 || matched != 1 {
+		t.Error("expected only the picked member to be traced as matched", trace)
+	}
+}
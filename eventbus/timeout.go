@@ -2,6 +2,8 @@ package eventbus
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 )
 
@@ -18,6 +20,8 @@ import (
 //     If timeout is positive, a new context with this timeout is
 //     derived from ctx and passed to the function. If it is zero or
 //     negative, the function is executed with the original ctx.
+//   - timeoutErr: The sentinel error identifying this timeout, such as
+//     ErrHandlerTimeout or ErrPublishTimeout.
 //   - fn: The function to be executed. It should take a context as a parameter.
 //     The context passed to this function is canceled if the timeout
 //     elapses or the parent context is canceled, so the function can
@@ -28,6 +32,8 @@ import (
 //     elapses, the error returned by the function (or nil) is returned.
 //   - If the context is canceled or the timeout elapses before the function
 //     completes, an error indicating that the context was canceled is returned.
+//   - If it was this timeout that elapsed, rather than a deadline of ctx, the
+//     error wraps both timeoutErr and context.DeadlineExceeded.
 //
 // Note:
 //   - If the function takes a long time to execute, this function will block
@@ -35,52 +41,57 @@ import (
 //     is reached.
 //   - If the context's timeout elapses before the function has finished executing,
 //     the goroutine running the function will keep running until it's done.
-func doWithTimeout(ctx context.Context, timeout time.Duration, fn func(context.Context) error) error {
+//     Its result is buffered and discarded, so the goroutine exits as soon as
+//     the function returns.
+func doWithTimeout(ctx context.Context, timeout time.Duration, timeoutErr error, fn func(context.Context) error) error {
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
 
+	parent := ctx
 	if timeout > 0 {
 		var cancel context.CancelFunc
 		ctx, cancel = context.WithTimeout(ctx, timeout)
 		defer cancel()
 	}
 
-	done := make(chan error)
+	done := make(chan error, 1)
 	go func() {
 		done <- fn(ctx)
 	}()
 
+	var err error
 	select {
 	case <-ctx.Done():
-		return ctx.Err()
-	case err := <-done:
-		return err
+		err = ctx.Err()
+	case err = <-done:
+	}
+
+	if timeout > 0 && parent.Err() == nil && errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+		return fmt.Errorf("%w: %w", timeoutErr, err)
 	}
+	return err
 }
 
 // shortestDuration takes a variadic number of time.Duration values and returns the
-// shortest duration among them. If no durations are passed, it returns 0.
+// shortest positive duration among them. Non-positive durations mean "no
+// timeout" and are ignored. If no positive durations are passed, it returns 0.
 //
 // Parameters:
 //   - durations: A variadic number of time.Duration values.
 //
 // Returns:
-//   - The shortest duration among the passed durations, or 0 if no durations
-//     were passed.
+//   - The shortest positive duration among the passed durations, or 0 if no
+//     positive durations were passed.
 //
 // Example:
 //
-//	shortest := shortestDuration(time.Second, 2*time.Second, 500*time.Millisecond)
+//	shortest := shortestDuration(time.Second, 0, 500*time.Millisecond)
 //	fmt.Println(shortest) // Output: 500ms
 func shortestDuration(durations ...time.Duration) time.Duration {
-	if len(durations) == 0 {
-		return 0 // or return a special value to indicate no durations were passed
-	}
-
-	shortest := durations[0]
-	for _, d := range durations[1:] { // Start iterating from index 1
-		if d < shortest {
+	var shortest time.Duration
+	for _, d := range durations {
+		if d > 0 && (shortest == 0 || d < shortest) {
 			shortest = d
 		}
 	}
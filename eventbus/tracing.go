@@ -0,0 +1,51 @@
+package eventbus
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span attribute keys.
+const (
+	eventIDKey        = attribute.Key("eventbus.event.id")
+	subscriptionIDKey = attribute.Key("eventbus.subscription.id")
+	observerIDKey     = attribute.Key("eventbus.observer.id")
+)
+
+// startSpan starts a child span of ctx on the bus tracer, which must be set.
+// The returned function ends the span, recording err on it if it isn't nil.
+func (b *bus) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, func(err error)) {
+	ctx, span := b.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// spanMatcher matches events by the span context of their publish.
+type spanMatcher func(trace.SpanContext) bool
+
+// SpanMatcher matches events whose publish context carries a span context that
+// pred accepts, for example to only handle events of sampled traces. Events
+// published without a span get an invalid span context.
+func SpanMatcher(pred func(trace.SpanContext) bool) ContextMatcher {
+	return spanMatcher(pred)
+}
+
+func (m spanMatcher) Match(name Stringer, data interface{}) bool {
+	return m.MatchContext(context.Background(), name, data)
+}
+
+func (m spanMatcher) MatchContext(ctx context.Context, _ Stringer, _ interface{}) bool {
+	return m(trace.SpanContextFromContext(ctx))
+}
+
+func (m spanMatcher) String() string {
+	return "span"
+}
@@ -0,0 +1,127 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestTracer() (trace.Tracer, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return provider.Tracer("eventbus_test"), recorder
+}
+
+func TestPublish_WithTracer_HandlerAndObserverSpansAreChildren(t *testing.T) {
+	ctx := context.Background()
+	tracer, recorder := newTestTracer()
+	bus := eventbus.New(eventbus.WithTracerBusOpt(tracer), eventbus.WithContextKeysBusOpt())
+	errFailed := errors.New("failed")
+	var handlerSpan trace.SpanContext
+	s := bus.On(testEvent)
+	s.Do(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) error {
+		handlerSpan = trace.SpanContextFromContext(ctx)
+		return errFailed
+	})
+	observerID := bus.AddObserver(observerFunc(func(context.Context, eventbus.Stringer, interface{}) {}))
+
+	if err := bus.Publish(ctx, testEvent, nil); !errors.Is(err, errFailed) {
+		t.Error("expected handler error", err)
+	}
+	bus.Flush(ctx)
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	publish, handler, observer := spans[testEvent.String()], spans["handler"], spans["observer"]
+	if publish == nil || handler == nil || observer == nil {
+		t.Fatal("expected publish, handler and observer spans", spans)
+	}
+	if handler.Parent().SpanID() != publish.SpanContext().SpanID() || observer.Parent().SpanID() != publish.SpanContext().SpanID() {
+		t.Error("expected the handler and observer spans to be children of the publish span")
+	}
+	if handlerSpan.SpanID() != handler.SpanContext().SpanID() {
+		t.Error("expected the handler to get the context of its span")
+	}
+	if publish.Status().Code != codes.Error || handler.Status().Code != codes.Error || len(handler.Events()) == 0 {
+		t.Error("expected the error to be recorded on the spans", publish.Status(), handler.Status())
+	}
+	if !hasAttribute(handler, "eventbus.subscription.id", s.String()) || !hasAttribute(observer, "eventbus.observer.id", observerID) {
+		t.Error("expected the spans to name their subscription and observer")
+	}
+	if !handler.EndTime().After(handler.StartTime()) {
+		t.Error("expected the handler span to capture its duration")
+	}
+}
+
+func TestPublish_WithoutTracer_HandlerContextHasNoSpan(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var span trace.SpanContext
+	bus.On(testEvent).Do(func(ctx context.Context, _ eventbus.Stringer, _ interface{}) error {
+		span = trace.SpanContextFromContext(ctx)
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, nil); err != nil {
+		t.Error("expected no error", err)
+	}
+	if span.IsValid() {
+		t.Error("expected no span", span)
+	}
+}
+
+func hasAttribute(span sdktrace.ReadOnlySpan, key, value string) bool {
+	for _, attr := range span.Attributes() {
+		if string(attr.Key) == key && attr.Value.AsString() == value {
+			return true
+		}
+	}
+	return false
+}
+
+// spanContext returns a context carrying a remote span context with the
+// sampling flag set as given.
+func spanContext(sampled bool) context.Context {
+	var flags trace.TraceFlags
+	if sampled {
+		flags = trace.FlagsSampled
+	}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: flags,
+		Remote:     true,
+	})
+	return trace.ContextWithRemoteSpanContext(context.Background(), sc)
+}
+
+func TestSpanMatcher_SampledOnly_RoutesBySamplingFlag(t *testing.T) {
+	bus := eventbus.New()
+	var sampled, unsampled int
+	bus.When(eventbus.SpanMatcher(trace.SpanContext.IsSampled)).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		sampled++
+		return nil
+	})
+	bus.When(eventbus.Not(eventbus.SpanMatcher(trace.SpanContext.IsSampled))).Do(func(context.Context, eventbus.Stringer, interface{}) error {
+		unsampled++
+		return nil
+	})
+
+	for _, ctx := range []context.Context{spanContext(true), spanContext(false), spanContext(true), context.Background()} {
+		if err := bus.Publish(ctx, testEvent, nil); err != nil {
+			t.Error("expected no error", err)
+		}
+	}
+
+	if sampled != 2 || unsampled != 2 {
+		t.Error("expected events to be routed by the sampling flag", sampled, unsampled)
+	}
+}
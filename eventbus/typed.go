@@ -0,0 +1,41 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// TypedSubscription is a subscription whose handlers receive the event data as
+// a T. By default a handler fails with ErrDataType when the data isn't a T.
+type TypedSubscription[T any] struct {
+	*subscription
+	skipMismatched bool
+}
+
+// Subscribes to an event by name with handlers that receive the data as a T.
+func OnTyped[T any](b *bus, name Stringer) *TypedSubscription[T] {
+	return &TypedSubscription[T]{subscription: b.On(name)}
+}
+
+// SkipMismatched makes handlers added afterwards ignore events whose data isn't
+// a T instead of failing.
+func (s *TypedSubscription[T]) SkipMismatched() *TypedSubscription[T] {
+	s.skipMismatched = true
+	return s
+}
+
+// Assigns the function to be executed when the event is published.
+func (s *TypedSubscription[T]) Do(fn func(ctx context.Context, name Stringer, data T) error) {
+	skip := s.skipMismatched
+	s.subscription.Do(func(ctx context.Context, name Stringer, data interface{}) error {
+		typed, ok := data.(T)
+		if !ok {
+			if skip {
+				return nil
+			}
+			return fmt.Errorf("%w: expected %v, got %T", ErrDataType, reflect.TypeOf((*T)(nil)).Elem(), data)
+		}
+		return fn(ctx, name, typed)
+	})
+}
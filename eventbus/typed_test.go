@@ -0,0 +1,71 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+)
+
+type orderCreated struct {
+	ID    string
+	Total int
+}
+
+func TestOnTyped_MatchingData_HandlerReceivesTypedValue(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	var received orderCreated
+	eventbus.OnTyped[orderCreated](bus, testEvent).Do(func(_ context.Context, _ eventbus.Stringer, data orderCreated) error {
+		received = data
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, orderCreated{ID: "42", Total: 7}); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if received.ID != "42" || received.Total != 7 {
+		t.Error("expected handler to receive the published data", received)
+	}
+}
+
+func TestOnTyped_MismatchedData_ReturnsErrDataType(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	called := false
+	eventbus.OnTyped[orderCreated](bus, testEvent).Do(func(_ context.Context, _ eventbus.Stringer, _ orderCreated) error {
+		called = true
+		return nil
+	})
+
+	err := bus.Publish(ctx, testEvent, "not an order")
+	if !errors.Is(err, eventbus.ErrDataType) {
+		t.Error("expected data type error", err)
+	}
+	if called {
+		t.Error("expected handler to not be called")
+	}
+}
+
+func TestOnTyped_SkipMismatched_IgnoresMismatchedData(t *testing.T) {
+	ctx := context.Background()
+	bus := eventbus.New()
+	called := 0
+	eventbus.OnTyped[orderCreated](bus, testEvent).SkipMismatched().Do(func(_ context.Context, _ eventbus.Stringer, _ orderCreated) error {
+		called++
+		return nil
+	})
+
+	if err := bus.Publish(ctx, testEvent, "not an order"); err != nil {
+		t.Error("expected no error", err)
+	}
+	if err := bus.Publish(ctx, testEvent, orderCreated{}); err != nil {
+		t.Error("expected no error", err)
+	}
+
+	if called != 1 {
+		t.Error("expected handler to be called only for matching data", called)
+	}
+}
@@ -0,0 +1,42 @@
+package eventbus
+
+import (
+	"context"
+
+	"github.com/almahoozi/go-eventbus/pkg/id"
+)
+
+// Waits for the next event that m matches to be published, and returns it.
+// The event is captured by a temporary subscription, which is removed before
+// WaitForEvent returns. Returns the error of ctx if it is done first. This is
+// mostly useful in tests.
+func (b *bus) WaitForEvent(ctx context.Context, m Matcher) (Event, error) {
+	if ctx.Err() != nil {
+		return Event{}, ctx.Err()
+	}
+
+	captured := make(chan Event, 1)
+	// The handler is attached before the subscription is registered, so that
+	// no event can use up the once subscription without being captured.
+	s := newSubscription(b, id.New(), m).Once()
+	s.Do(func(ctx context.Context, name Stringer, data interface{}) error {
+		e, ok := eventFromContext(ctx)
+		if !ok {
+			e = Event{Name: name, Data: data}
+		}
+		select {
+		case captured <- Event{ID: e.ID, Name: e.Name, Data: e.Data, Timestamp: e.Timestamp, Headers: e.Headers}:
+		default:
+		}
+		return nil
+	})
+	b.addMatcherSubscription(s)
+	defer s.Unsubscribe()
+
+	select {
+	case <-ctx.Done():
+		return Event{}, ctx.Err()
+	case e := <-captured:
+		return e, nil
+	}
+}
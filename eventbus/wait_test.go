@@ -0,0 +1,76 @@
+package eventbus_test
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/almahoozi/go-eventbus/eventbus"
+)
+
+func TestWaitForEvent_MatchingEventPublished_ReturnsEvent(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	bus := eventbus.New()
+
+	go func() {
+		for bus.SubscriptionCount() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		_ = bus.Publish(ctx, EventName("other"), "ignored")
+		_ = bus.Publish(ctx, testEvent, "data", eventbus.WithHeaderEventOpt("key", "value"))
+	}()
+
+	e, err := bus.WaitForEvent(ctx, eventbus.ExactMatcher(testEvent))
+	if err != nil {
+		t.Fatal("expected no error", err)
+	}
+	if e.Name != testEvent || e.Data != "data" || e.Headers["key"] != "value" || e.ID == "" {
+		t.Error("expected the published event", e)
+	}
+	if n := bus.SubscriptionCount(); n != 0 {
+		t.Error("expected the temporary subscription to be removed", n)
+	}
+}
+
+func TestWaitForEvent_ContextDone_ReturnsErrorAndUnsubscribes(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	bus := eventbus.New()
+
+	if _, err := bus.WaitForEvent(ctx, eventbus.ExactMatcher(testEvent)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("expected context.DeadlineExceeded", err)
+	}
+	if n := bus.SubscriptionCount(); n != 0 {
+		t.Error("expected the temporary subscription to be removed", n)
+	}
+}
+
+func TestWaitForEvent_EventsPublishedWhileSubscribing_CapturesOne(t *testing.T) {
+	bus := eventbus.New()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				_ = bus.Publish(context.Background(), testEvent, nil)
+				runtime.Gosched()
+			}
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		_, err := bus.WaitForEvent(ctx, eventbus.ExactMatcher(testEvent))
+		cancel()
+		if err != nil {
+			t.Fatal("expected an event to be captured", err)
+		}
+	}
+}